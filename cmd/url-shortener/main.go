@@ -6,14 +6,36 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
+	"url-shortener/internal/api/openapi"
 	ssogrpc "url-shortener/internal/clients/sso/grpc"
 	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/handlers/url/audit"
 	"url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/list"
+	"url-shortener/internal/http-server/handlers/url/qr"
 	"url-shortener/internal/http-server/handlers/url/redirect"
+	"url-shortener/internal/http-server/handlers/url/restore"
 	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/stats"
+	"url-shortener/internal/http-server/handlers/url/tags"
+	"url-shortener/internal/http-server/handlers/url/transfer"
+	"url-shortener/internal/http-server/handlers/url/update"
+	"url-shortener/internal/http-server/middleware/clientip"
 	mwLogger "url-shortener/internal/http-server/middleware/logger"
+	mwOpenAPI "url-shortener/internal/http-server/middleware/openapi"
 	"url-shortener/internal/http-server/middleware/sso"
+	mwWorkspace "url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/geoip"
+	"url-shortener/internal/lib/linkchain"
+	"url-shortener/internal/lib/purge"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/lib/validation"
+	"url-shortener/internal/storage/cache"
+	"url-shortener/internal/storage/redis"
 	"url-shortener/internal/storage/sqlite"
 	sl "url-shortener/pkg/logger/slog"
 
@@ -44,26 +66,158 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = storage.SetCaseInsensitiveAliases(cfg.AliasPolicy.CaseInsensitive); err != nil {
+		log.Error("failed to set alias case sensitivity", sl.Err(err))
+		os.Exit(1)
+	}
+
+	aliasPolicy, err := aliaspolicy.New(
+		cfg.AliasPolicy.Pattern,
+		cfg.AliasPolicy.MinLength,
+		cfg.AliasPolicy.MaxLength,
+		cfg.AliasPolicy.Reserved,
+	)
+	if err != nil {
+		log.Error("failed to init alias policy", sl.Err(err))
+		os.Exit(1)
+	}
+
+	validate, err := validation.New(cfg.URLPolicy, aliasPolicy)
+	if err != nil {
+		log.Error("failed to init validation", sl.Err(err))
+		os.Exit(1)
+	}
+
+	workspaces := mwWorkspace.NewDirectory()
+	for _, w := range cfg.Workspaces {
+		if err = workspaces.Add(w.Name, w.Prefix, w.Members, w.Domains); err != nil {
+			log.Error("failed to init workspaces", sl.Err(err))
+			os.Exit(1)
+		}
+	}
+
+	shortURLs := shorturl.New(cfg.PublicBaseURL, cfg.Domains(), cfg.DomainBaseURLs)
+
+	var countryResolver redirect.CountryResolver
+	var geoDB *geoip.DB
+	if cfg.GeoIPPath != "" {
+		geoDB, err = geoip.Open(cfg.GeoIPPath)
+		if err != nil {
+			log.Error("failed to open geoip database", sl.Err(err))
+			os.Exit(1)
+		}
+		countryResolver = geoDB
+	}
+
+	var cachedStorage cache.Cached
+	var redisStorage *redis.Storage
+	if cfg.Redis.Address != "" {
+		redisStorage, err = redis.New(
+			storage,
+			cfg.Redis.Address,
+			cfg.Redis.Password,
+			cfg.Redis.DB,
+			cfg.Redis.Timeout,
+			cfg.Redis.TTL,
+			cfg.Redis.NegativeTTL,
+		)
+		if err != nil {
+			log.Error("failed to init redis cache", sl.Err(err))
+			os.Exit(1)
+		}
+		cachedStorage = redisStorage
+	} else {
+		cachedStorage = cache.New(storage, cfg.Cache.Size, cfg.Cache.TTL, cfg.Cache.NegativeTTL)
+	}
+
+	chains := linkchain.New(cachedStorage, linkchain.Own{
+		Hosts:          cfg.OwnHosts(),
+		Prefixes:       cfg.Prefixes(),
+		Domains:        cfg.Domains(),
+		DomainBaseURLs: cfg.DomainBaseURLs,
+	}, cfg.LinkChains.MaxDepth, cfg.LinkChains.Flatten)
+
+	apiDoc, err := openapi.Load()
+	if err != nil {
+		log.Error("failed to load openapi document", sl.Err(err))
+		os.Exit(1)
+	}
+	validateRequests, err := mwOpenAPI.Validate(apiDoc)
+	if err != nil {
+		log.Error("failed to init request validation", sl.Err(err))
+		os.Exit(1)
+	}
+	serveAPIDoc, err := openapi.Serve(apiDoc)
+	if err != nil {
+		log.Error("failed to init openapi handler", sl.Err(err))
+		os.Exit(1)
+	}
+
+	resolveClientIP, err := clientip.Resolve(cfg.HTTPServer.TrustedProxies)
+	if err != nil {
+		log.Error("failed to init trusted proxies", sl.Err(err))
+		os.Exit(1)
+	}
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
+	router.Use(resolveClientIP)
 	router.Use(mwLogger.New(log))
 	router.Use(middleware.Recoverer)
+	router.Use(serveAPIDoc)
 	router.Use(middleware.URLFormat)
 
 	router.Route("/url", func(r chi.Router) {
 		r.Use(sso.IsRequestAdmin("url-shortener", ssoClient, cfg.Clients.SSO.Timeout))
-		r.Post("/", save.NewURL(storage))
-		r.Delete("/{alias}", delete.DeleteURL(storage))
+		r.Use(mwWorkspace.Resolve(workspaces))
+		r.Use(validateRequests)
+		r.Get("/", list.ListURLs(storage, shortURLs))
+		r.Post("/", save.NewURL(cachedStorage, chains, validate, shortURLs))
+		r.Get("/audit", audit.ListAudit(storage))
+		r.Get("/stats", stats.GroupStats(storage))
+		r.Post("/import", transfer.Import(storage, cachedStorage, chains, validate, shortURLs))
+		r.Get("/export", transfer.Export(storage))
+		r.Route("/{alias}", func(r chi.Router) {
+			r.Use(mwWorkspace.OwnsAlias(storage))
+			r.Patch("/", update.UpdateURL(storage, cachedStorage, chains, validate, shortURLs))
+			r.Delete("/", delete.DeleteURL(cachedStorage))
+			r.Post("/restore", restore.RestoreURL(cachedStorage))
+			r.Get("/qr", qr.QRCode(cachedStorage, shortURLs))
+			r.Get("/stats", stats.LinkStats(storage))
+			r.Post("/tags", tags.AddTags(storage))
+			r.Delete("/tags", tags.RemoveTag(storage))
+		})
 	})
 
-	router.Get("/{alias}", redirect.Redirect(storage))
+	redirectHandler := redirect.Redirect(cachedStorage, countryResolver, storage, storage)
+	router.Group(func(r chi.Router) {
+		r.Use(mwWorkspace.Host(workspaces))
+		redirectRoutes(r, redirectHandler)
+	})
+	for _, w := range workspaces.Prefixed() {
+		router.Route("/"+w.Prefix, func(r chi.Router) {
+			r.Use(mwWorkspace.Set(w))
+			redirectRoutes(r, redirectHandler)
+		})
+	}
+
+	aliasPolicy.Reserve(aliaspolicy.Routes...)
+	aliasPolicy.Reserve(cfg.Prefixes()...)
+	for _, route := range topLevelRoutes(router) {
+		if !slices.Contains(aliaspolicy.Routes, route) && !slices.Contains(cfg.Prefixes(), route) {
+			log.Error("top-level route missing from aliaspolicy.Routes", slog.String("route", route))
+			os.Exit(1)
+		}
+	}
 
 	log.Info("starting server", slog.String("address", cfg.Address))
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	go purge.Run(ctx, log, storage, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+
 	server := &http.Server{
 		Addr:         cfg.Address,
 		Handler:      router,
@@ -97,5 +251,41 @@ func main() {
 		return
 	}
 
+	if redisStorage != nil {
+		if err = redis.Close(redisStorage); err != nil {
+			log.Error("failed to close redis", sl.Err(err))
+			return
+		}
+	}
+
+	if geoDB != nil {
+		if err = geoDB.Close(); err != nil {
+			log.Error("failed to close geoip database", sl.Err(err))
+			return
+		}
+	}
+
 	log.Info("Server stopped")
 }
+
+// redirectRoutes serves the aliases of a namespace, with the extra path and
+// the password form of a link.
+func redirectRoutes(r chi.Router, redirectHandler http.HandlerFunc) {
+	r.Get("/{alias}", redirectHandler)
+	r.Get("/{alias}/*", redirectHandler)
+	r.Post("/{alias}", redirectHandler)
+	r.Post("/{alias}/*", redirectHandler)
+}
+
+// topLevelRoutes returns the static first path segment of every route.
+func topLevelRoutes(router chi.Routes) []string {
+	var routes []string
+	_ = chi.Walk(router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		if segment != "" && !strings.HasPrefix(segment, "{") {
+			routes = append(routes, segment)
+		}
+		return nil
+	})
+	return routes
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/lib/linkfile"
+	"url-shortener/internal/lib/random"
+	"url-shortener/internal/storage"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func (a *app) create(args []string) error {
+	const caller = "urlctl.create"
+
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	url := fs.String("url", "", "destination url")
+	alias := fs.String("alias", "", "alias, random if empty")
+	tags := fs.String("tags", "", "comma separated tags")
+	folder := fs.String("folder", "", "folder")
+	workspaceOf := a.workspaceFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+	ws, err := workspaceOf()
+	if err != nil {
+		return err
+	}
+
+	row := linkfile.Row{Alias: *alias, URL: *url, Folder: *folder}
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			row.Tags = append(row.Tags, tag)
+		}
+	}
+	if err = a.validate.Struct(row); err != nil {
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			return fmt.Errorf("%s: %s", caller, resp.ValidationError(validateErr).Error)
+		}
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	if row.Alias == "" {
+		row.Alias = random.NewRandomString(a.cfg.AliasLength)
+	}
+
+	link := storage.Link{
+		Alias:     ws.Alias(row.Alias),
+		URL:       row.URL,
+		Workspace: ws.Name,
+		Folder:    row.Folder,
+		Tags:      row.Tags,
+		Owner:     a.actor,
+	}
+	if err = a.chains.Resolve(&link); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	err = a.links.SaveURL(link, a.event(ws.Name, storage.AuditActionCreate, link.Alias, "", link.URL))
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	fmt.Fprintln(a.stdout, a.shortURLs.URL(nil, link.Alias))
+	return nil
+}
+
+func (a *app) delete(args []string) error {
+	const caller = "urlctl.delete"
+
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	workspaceOf := a.workspaceFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	ws, err := workspaceOf()
+	if err != nil {
+		return err
+	}
+
+	link, err := a.storage.GetURL(ws.Alias(fs.Arg(0)))
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	if link.Workspace != ws.Name {
+		return fmt.Errorf("%s: %w", caller, storage.ErrURLNotFound)
+	}
+	if err = a.links.DeleteURL(link.Alias, a.event(ws.Name, storage.AuditActionDelete, link.Alias, "", "")); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+func (a *app) list(args []string) error {
+	const caller = "urlctl.list"
+
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	tag := fs.String("tag", "", "only links with the tag")
+	folder := fs.String("folder", "", "only links in the folder")
+	limit := fs.Int("limit", 100, "maximum number of links, 0 for all")
+	workspaceOf := a.workspaceFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *limit < 0 {
+		return errUsage
+	}
+	ws, err := workspaceOf()
+	if err != nil {
+		return err
+	}
+
+	filter := storage.LinkFilter{
+		Workspace:    ws.Name,
+		Namespace:    ws.Namespace(),
+		OwnNamespace: true,
+		Tag:          *tag,
+		Folder:       *folder,
+		Limit:        *limit,
+	}
+	links, err := a.storage.ListURLs(filter)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ALIAS\tURL\tFOLDER\tTAGS\tOWNER\tCREATED")
+	for _, link := range links {
+		created := ""
+		if !link.CreatedAt.IsZero() {
+			created = link.CreatedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			ws.Unalias(link.Alias), link.URL, link.Folder, strings.Join(link.Tags, ","), link.Owner, created)
+	}
+	return w.Flush()
+}
+
+// shownLink is a link as printed by show, without its password hash.
+type shownLink struct {
+	Alias        string                `json:"alias"`
+	ShortURL     string                `json:"short_url"`
+	URL          string                `json:"url"`
+	RedirectType int                   `json:"redirect_type,omitempty"`
+	Passthrough  bool                  `json:"passthrough,omitempty"`
+	Rules        []storage.Rule        `json:"rules,omitempty"`
+	Destinations []storage.Destination `json:"destinations,omitempty"`
+	ActiveFrom   *time.Time            `json:"active_from,omitempty"`
+	ExpiresAt    *time.Time            `json:"expires_at,omitempty"`
+	MaxClicks    int                   `json:"max_clicks,omitempty"`
+	Protected    bool                  `json:"protected,omitempty"`
+	Workspace    string                `json:"workspace,omitempty"`
+	Folder       string                `json:"folder,omitempty"`
+	Tags         []string              `json:"tags,omitempty"`
+	Owner        string                `json:"owner,omitempty"`
+	CreatedAt    *time.Time            `json:"created_at,omitempty"`
+}
+
+func (a *app) show(args []string) error {
+	const caller = "urlctl.show"
+
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	workspaceOf := a.workspaceFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	ws, err := workspaceOf()
+	if err != nil {
+		return err
+	}
+
+	link, err := a.storage.GetURL(ws.Alias(fs.Arg(0)))
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	if link.Workspace != ws.Name {
+		return fmt.Errorf("%s: %w", caller, storage.ErrURLNotFound)
+	}
+
+	shown := shownLink{
+		Alias:        ws.Unalias(link.Alias),
+		ShortURL:     a.shortURLs.URL(nil, link.Alias),
+		URL:          link.URL,
+		RedirectType: link.RedirectType,
+		Passthrough:  link.Passthrough,
+		Rules:        link.Rules,
+		Destinations: link.Destinations,
+		MaxClicks:    link.MaxClicks,
+		Protected:    link.PasswordHash != "",
+		Workspace:    link.Workspace,
+		Folder:       link.Folder,
+		Tags:         link.Tags,
+		Owner:        link.Owner,
+	}
+	if !link.ActiveFrom.IsZero() {
+		shown.ActiveFrom = &link.ActiveFrom
+	}
+	if !link.ExpiresAt.IsZero() {
+		shown.ExpiresAt = &link.ExpiresAt
+	}
+	if !link.CreatedAt.IsZero() {
+		shown.CreatedAt = &link.CreatedAt
+	}
+
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(shown)
+}
+
+func (a *app) stats(args []string) error {
+	const caller = "urlctl.stats"
+
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	tag := fs.String("tag", "", "sum the counters of the links with this tag")
+	folder := fs.String("folder", "", "sum the counters of the links in this folder")
+	workspaceOf := a.workspaceFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 || (fs.NArg() == 1 && (*tag != "" || *folder != "")) {
+		return errUsage
+	}
+	ws, err := workspaceOf()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+
+	if *tag != "" || *folder != "" {
+		stats, err := a.storage.GroupStats(storage.LinkFilter{
+			Workspace:    ws.Name,
+			Namespace:    ws.Namespace(),
+			OwnNamespace: true,
+			Tag:          *tag,
+			Folder:       *folder,
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+		fmt.Fprintf(w, "links\t%d\n", stats.Links)
+		fmt.Fprintf(w, "variant hits\t%d\n", stats.VariantHits)
+		fmt.Fprintf(w, "redeemed clicks\t%d\n", stats.RedeemedClicks)
+		return w.Flush()
+	}
+
+	if fs.NArg() == 0 {
+		stats, err := a.storage.Stats()
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+		fmt.Fprintf(w, "links\t%d\n", stats.Links)
+		fmt.Fprintf(w, "deleted\t%d\n", stats.Deleted)
+		fmt.Fprintf(w, "tags\t%d\n", stats.Tags)
+		fmt.Fprintf(w, "audit events\t%d\n", stats.AuditEvents)
+		fmt.Fprintf(w, "variant hits\t%d\n", stats.VariantHits)
+		fmt.Fprintf(w, "redeemed clicks\t%d\n", stats.RedeemedClicks)
+		return w.Flush()
+	}
+
+	alias := ws.Alias(fs.Arg(0))
+	owner, err := a.storage.LinkWorkspace(alias)
+	if err == nil && owner != ws.Name {
+		err = storage.ErrURLNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	stats, err := a.storage.LinkStats(alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	fmt.Fprintf(w, "alias\t%s\n", ws.Unalias(stats.Alias))
+	fmt.Fprintf(w, "deleted\t%t\n", stats.Deleted)
+	if stats.MaxClicks > 0 {
+		fmt.Fprintf(w, "clicks\t%d of %d\n", stats.MaxClicks-stats.RemainingClicks, stats.MaxClicks)
+	}
+	for i, destination := range stats.Destinations {
+		fmt.Fprintf(w, "destination %d\t%s\tweight %d\t%d hits\n", i, destination.URL, destination.Weight, destination.Hits)
+	}
+	return w.Flush()
+}
+
+func (a *app) importLinks(args []string) error {
+	const caller = "urlctl.import"
+
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := fs.String("file", "", "file to import, - for stdin")
+	format := fs.String("format", "", "csv or jsonl, from the extension of the file if empty")
+	onConflict := fs.String("on-conflict", linkfile.ConflictFail, "skip, overwrite or fail")
+	dryRun := fs.Bool("dry-run", false, "check the file without saving")
+	workspaceOf := a.workspaceFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *file == "" {
+		return errUsage
+	}
+	switch *onConflict {
+	case linkfile.ConflictSkip, linkfile.ConflictOverwrite, linkfile.ConflictFail:
+	default:
+		return fmt.Errorf("%s: invalid -on-conflict %q: %w", caller, *onConflict, errUsage)
+	}
+	if *format == "" {
+		*format = linkfile.FormatJSONL
+		if strings.HasSuffix(*file, ".csv") {
+			*format = linkfile.FormatCSV
+		}
+	}
+	if err := linkfile.CheckFormat(*format); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	ws, err := workspaceOf()
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	rows, err := linkfile.NewReader(*format, in)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	importer := linkfile.Importer{
+		Validate:    a.validate.Validate,
+		Policy:      a.validate.URLPolicy,
+		Chains:      a.chains,
+		AliasLength: a.cfg.AliasLength,
+		Workspace:   ws,
+		Owner:       a.actor,
+		OnConflict:  *onConflict,
+		Event:       a.event(ws.Name, storage.AuditActionImport, "", "", ""),
+	}
+	batch, err := importer.Prepare(rows)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	imp, err := a.storage.BeginImport()
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	defer imp.Rollback()
+
+	report, err := importer.Save(imp, batch)
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	for _, row := range report.Rows {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", row.Row, row.Alias, row.Result, row.Error)
+	}
+	fmt.Fprintf(w, "imported %d, overwritten %d, skipped %d, failed %d\n",
+		report.Imported, report.Overwritten, report.Skipped, report.Failed)
+	if flushErr := w.Flush(); flushErr != nil && err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if *dryRun {
+		return nil
+	}
+	if err = imp.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	// The import is committed, every alias is invalidated even if one fails.
+	var errs []error
+	for _, link := range report.Saved {
+		if err = a.links.Invalidate(link.Alias); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err = errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s: links imported: %w", caller, err)
+	}
+
+	return nil
+}
+
+func (a *app) export(args []string) error {
+	const caller = "urlctl.export"
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", linkfile.FormatJSONL, "csv or jsonl")
+	output := fs.String("o", "", "file to write, stdout if empty")
+	workspaceOf := a.workspaceFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+	if err := linkfile.CheckFormat(*format); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	ws, err := workspaceOf()
+	if err != nil {
+		return err
+	}
+
+	out := a.stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	rows, err := linkfile.NewWriter(*format, out)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	filter := storage.LinkFilter{Workspace: ws.Name, Namespace: ws.Namespace(), OwnNamespace: true}
+	err = a.storage.ExportURLs(filter, func(link storage.Link) error {
+		return rows.Write(linkfile.NewRow(ws, link))
+	})
+	if err == nil {
+		err = rows.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+func (a *app) vacuum(args []string) error {
+	const caller = "urlctl.vacuum"
+
+	fs := flag.NewFlagSet("vacuum", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	purged, err := a.storage.PurgeDeleted(time.Now().Add(-a.cfg.Trash.Retention), a.actor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = a.storage.Vacuum(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	fmt.Fprintf(a.stdout, "purged %d deleted links\n", purged)
+	return nil
+}
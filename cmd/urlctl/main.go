@@ -0,0 +1,291 @@
+// Command urlctl manages the links of the configured storage directly,
+// without going through the server. It reads the same config as
+// url-shortener from CONFIG_PATH.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"url-shortener/internal/config"
+	mwWorkspace "url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/linkchain"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/lib/validation"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/redis"
+	"url-shortener/internal/storage/sqlite"
+	sl "url-shortener/pkg/logger/slog"
+)
+
+const usage = `usage: urlctl <command> [flags] [args]
+
+commands:
+  create  -url URL [-alias ALIAS] [-tags a,b] [-folder FOLDER]
+  delete  ALIAS
+  list    [-tag TAG] [-folder FOLDER] [-limit N]
+  show    ALIAS
+  stats   [-tag TAG] [-folder FOLDER] [ALIAS]
+  import  -file FILE [-format csv|jsonl] [-on-conflict skip|overwrite|fail] [-dry-run]
+  export  [-format csv|jsonl] [-o FILE]
+  vacuum
+
+Every command but vacuum takes -workspace NAME and -domain DOMAIN to work on
+the links of a workspace, flags go before the arguments.
+`
+
+// errUsage is returned for invalid arguments, the usage is printed with it.
+var errUsage = errors.New("invalid arguments")
+
+// links saves and deletes links, through the shared cache when there is one.
+type links interface {
+	SaveURL(link storage.Link, events ...storage.AuditEvent) error
+	DeleteURL(alias string, events ...storage.AuditEvent) error
+	Invalidate(alias string) error
+}
+
+// uncached is used without a shared cache. The servers keep serving what
+// their in-process cache holds until it expires, the first change of a
+// command warns about it.
+type uncached struct {
+	*sqlite.Storage
+	cache  config.Cache
+	stderr io.Writer
+	warned bool
+}
+
+func (u *uncached) SaveURL(link storage.Link, events ...storage.AuditEvent) error {
+	if err := u.Storage.SaveURL(link, events...); err != nil {
+		return err
+	}
+	return u.Invalidate(link.Alias)
+}
+
+func (u *uncached) DeleteURL(alias string, events ...storage.AuditEvent) error {
+	if err := u.Storage.DeleteURL(alias, events...); err != nil {
+		return err
+	}
+	return u.Invalidate(alias)
+}
+
+func (u *uncached) Invalidate(string) error {
+	if !u.warned {
+		u.warned = true
+		fmt.Fprintf(u.stderr, "urlctl: warning: redis.address is not set, servers may serve the old links for up to "+
+			"cache.ttl (%s) and keep answering not found for new ones for up to cache.negative_ttl (%s)\n",
+			u.cache.TTL, u.cache.NegativeTTL)
+	}
+	return nil
+}
+
+type app struct {
+	cfg        *config.Config
+	storage    *sqlite.Storage
+	links      links
+	workspaces *mwWorkspace.Directory
+	validate   *validation.Validator
+	chains     *linkchain.Chains
+	shortURLs  *shorturl.Builder
+	actor      string
+	stdout     io.Writer
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "help" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Logs must not end up in the output of list or export.
+	sl.SetOutput(os.Stderr)
+
+	a, closeApp, err := newApp(config.MustLoad(), os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "urlctl: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = a.run(os.Args[1], os.Args[2:])
+	closeApp()
+	if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "urlctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newApp opens the storage of cfg. Commands print to stdout, warnings go to
+// stderr.
+func newApp(cfg *config.Config, stdout io.Writer, stderr io.Writer) (*app, func(), error) {
+	const caller = "urlctl.newApp"
+
+	s, err := sqlite.InitDB(cfg.StoragePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	closeApp := func() {
+		if err := sqlite.Close(context.Background(), s); err != nil {
+			fmt.Fprintf(stderr, "urlctl: failed to close db: %v\n", err)
+		}
+	}
+
+	if err = s.SetCaseInsensitiveAliases(cfg.AliasPolicy.CaseInsensitive); err != nil {
+		closeApp()
+		return nil, nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	a := &app{
+		cfg:     cfg,
+		storage: s,
+		links:   &uncached{Storage: s, cache: cfg.Cache, stderr: stderr},
+		stdout:  stdout,
+		actor:   "urlctl",
+	}
+	if u, err := user.Current(); err == nil {
+		a.actor = "urlctl:" + u.Username
+	}
+
+	if cfg.Redis.Address != "" {
+		redisStorage, err := redis.New(
+			s,
+			cfg.Redis.Address,
+			cfg.Redis.Password,
+			cfg.Redis.DB,
+			cfg.Redis.Timeout,
+			cfg.Redis.TTL,
+			cfg.Redis.NegativeTTL,
+		)
+		if err != nil {
+			closeApp()
+			return nil, nil, fmt.Errorf("%s: %w", caller, err)
+		}
+		a.links = redisStorage
+		closeDB := closeApp
+		closeApp = func() {
+			if err := redis.Close(redisStorage); err != nil {
+				fmt.Fprintf(stderr, "urlctl: failed to close redis: %v\n", err)
+			}
+			closeDB()
+		}
+	}
+
+	a.workspaces = mwWorkspace.NewDirectory()
+	for _, w := range cfg.Workspaces {
+		if err = a.workspaces.Add(w.Name, w.Prefix, w.Members, w.Domains); err != nil {
+			closeApp()
+			return nil, nil, fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	aliasPolicy, err := aliaspolicy.New(
+		cfg.AliasPolicy.Pattern,
+		cfg.AliasPolicy.MinLength,
+		cfg.AliasPolicy.MaxLength,
+		cfg.AliasPolicy.Reserved,
+	)
+	if err != nil {
+		closeApp()
+		return nil, nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	aliasPolicy.Reserve(aliaspolicy.Routes...)
+	aliasPolicy.Reserve(cfg.Prefixes()...)
+
+	a.validate, err = validation.New(cfg.URLPolicy, aliasPolicy)
+	if err != nil {
+		closeApp()
+		return nil, nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	a.chains = linkchain.New(s, linkchain.Own{
+		Hosts:          cfg.OwnHosts(),
+		Prefixes:       cfg.Prefixes(),
+		Domains:        cfg.Domains(),
+		DomainBaseURLs: cfg.DomainBaseURLs,
+	}, cfg.LinkChains.MaxDepth, cfg.LinkChains.Flatten)
+
+	baseURL := cfg.PublicBaseURL
+	if baseURL == "" {
+		baseURL = "http://" + cfg.HTTPServer.Address
+	}
+	a.shortURLs = shorturl.New(baseURL, cfg.Domains(), cfg.DomainBaseURLs)
+
+	return a, closeApp, nil
+}
+
+func (a *app) run(command string, args []string) error {
+	switch command {
+	case "create":
+		return a.create(args)
+	case "delete":
+		return a.delete(args)
+	case "list":
+		return a.list(args)
+	case "show":
+		return a.show(args)
+	case "stats":
+		return a.stats(args)
+	case "import":
+		return a.importLinks(args)
+	case "export":
+		return a.export(args)
+	case "vacuum":
+		return a.vacuum(args)
+	default:
+		return fmt.Errorf("unknown command %q: %w", command, errUsage)
+	}
+}
+
+// workspaceFlags adds -workspace and -domain to fs. The returned function
+// resolves them once fs is parsed.
+func (a *app) workspaceFlags(fs *flag.FlagSet) func() (mwWorkspace.Workspace, error) {
+	name := fs.String("workspace", "", "name of the workspace, the default one if empty")
+	domain := fs.String("domain", "", "custom domain of the workspace")
+
+	return func() (mwWorkspace.Workspace, error) {
+		const caller = "urlctl.workspace"
+
+		var ws mwWorkspace.Workspace
+		if *name != "" {
+			found := false
+			for _, w := range a.cfg.Workspaces {
+				if w.Name == *name {
+					ws = mwWorkspace.Workspace{Name: w.Name, Prefix: w.Prefix}
+					found = true
+					break
+				}
+			}
+			if !found {
+				return mwWorkspace.Workspace{}, fmt.Errorf("%s: unknown workspace %s", caller, *name)
+			}
+		}
+
+		if *domain != "" {
+			var err error
+			if ws, err = a.workspaces.OnDomain(ws, *domain); err != nil {
+				return mwWorkspace.Workspace{}, fmt.Errorf("%s: %w", caller, err)
+			}
+		}
+
+		return ws, nil
+	}
+}
+
+// event returns the audit event of a change made by the user of urlctl.
+func (a *app) event(workspace string, action string, alias string, oldValue string, newValue string) storage.AuditEvent {
+	return storage.AuditEvent{
+		Actor:     a.actor,
+		Workspace: workspace,
+		Action:    action,
+		Alias:     alias,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/internal/config"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/redis"
+	"url-shortener/internal/storage/sqlite"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestApp returns urlctl on a temporary database, with redis at
+// redisAddress if it is not empty.
+func newTestApp(t *testing.T, storagePath string, redisAddress string) (*app, *bytes.Buffer, *bytes.Buffer) {
+	cfg := *config.GetConfig()
+	cfg.StoragePath = storagePath
+	cfg.Redis.Address = redisAddress
+
+	var stdout, stderr bytes.Buffer
+	a, closeApp, err := newApp(&cfg, &stdout, &stderr)
+	require.NoError(t, err)
+	t.Cleanup(closeApp)
+
+	return a, &stdout, &stderr
+}
+
+func TestCommandsWithoutCache(t *testing.T) {
+	a, stdout, stderr := newTestApp(t, filepath.Join(t.TempDir(), "storage.db"), "")
+
+	require.NoError(t, a.run("create", []string{"-alias", "test", "-url", "https://93.184.216.34/"}))
+	require.Equal(t, "http://localhost:8081/test\n", stdout.String())
+
+	stdout.Reset()
+	require.NoError(t, a.run("show", []string{"test"}))
+	require.Contains(t, stdout.String(), `"url": "https://93.184.216.34/"`)
+
+	require.NoError(t, a.run("delete", []string{"test"}))
+	require.ErrorIs(t, a.run("show", []string{"test"}), storage.ErrURLNotFound)
+
+	// Only the first change warns.
+	require.Equal(t, 1, strings.Count(stderr.String(), "warning: redis.address is not set"), stderr.String())
+}
+
+func TestCommandsInvalidateRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	storagePath := filepath.Join(t.TempDir(), "storage.db")
+
+	a, _, stderr := newTestApp(t, storagePath, mr.Addr())
+
+	// A server sharing the database and the redis cache with urlctl.
+	db, err := sqlite.InitDB(storagePath)
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlite.Close(context.Background(), db) })
+	server, err := redis.New(db, mr.Addr(), "", 0, time.Second, time.Hour, time.Hour)
+	require.NoError(t, err)
+	t.Cleanup(func() { redis.Close(server) })
+
+	// The server caches that the alias is not found.
+	_, err = server.GetURL("test")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	require.NoError(t, a.run("create", []string{"-alias", "test", "-url", "https://93.184.216.34/"}))
+	link, err := server.GetURL("test")
+	require.NoError(t, err)
+	require.Equal(t, "https://93.184.216.34/", link.URL)
+
+	file := filepath.Join(t.TempDir(), "links.jsonl")
+	require.NoError(t, os.WriteFile(file, []byte(`{"alias":"test","url":"https://93.184.216.35/"}`+"\n"), 0o600))
+	require.NoError(t, a.run("import", []string{"-file", file, "-on-conflict", "overwrite"}))
+	link, err = server.GetURL("test")
+	require.NoError(t, err)
+	require.Equal(t, "https://93.184.216.35/", link.URL)
+
+	require.NoError(t, a.run("delete", []string{"test"}))
+	_, err = server.GetURL("test")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	require.Empty(t, stderr.String())
+}
@@ -10,23 +10,24 @@ var (
 	ErrInvalidStatusCode = errors.New("invalid status code")
 )
 
+// redirectClient returns redirects instead of following them.
+var redirectClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
 func GetRedirect(url string) (string, error) {
 	const caller = "api.GetRedirect"
 
-	client := &http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
-
-	resp, err := client.Get(url)
+	resp, err := redirectClient.Get(url)
 	if err != nil {
 		return "", err
 	}
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusFound {
+	if resp.StatusCode < http.StatusMultipleChoices || resp.StatusCode >= http.StatusBadRequest {
 		return "", fmt.Errorf("%s: %w: %d", caller, ErrInvalidStatusCode, resp.StatusCode)
 	}
 
@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// Load parses and validates the OpenAPI document of the API embedded in the
+// binary.
+func Load() (*openapi3.T, error) {
+	const caller = "api.openapi.Load"
+
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return doc, nil
+}
+
+// Path is the path the document is served at.
+const Path = "/openapi.json"
+
+// Serve serves doc as JSON on GET requests for exactly Path, other requests
+// go on to next. It must come before middleware.URLFormat, which routes
+// /openapi.json, /openapi.yaml and /openapi alike.
+func Serve(doc *openapi3.T) (func(next http.Handler) http.Handler, error) {
+	const caller = "api.openapi.Serve"
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != Path || r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body)
+		})
+	}, nil
+}
@@ -0,0 +1,194 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/handlers/url/audit"
+	"url-shortener/internal/http-server/handlers/url/list"
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/stats"
+	"url-shortener/internal/http-server/handlers/url/tags"
+	"url-shortener/internal/http-server/handlers/url/transfer"
+	"url-shortener/internal/http-server/handlers/url/update"
+	"url-shortener/internal/lib/linkfile"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	doc, err := Load()
+	require.NoError(t, err)
+	serve, err := Serve(doc)
+	require.NoError(t, err)
+
+	handler := serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	testCases := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{method: http.MethodGet, path: "/openapi.json", status: http.StatusOK},
+		// Left to the aliases.
+		{method: http.MethodGet, path: "/openapi", status: http.StatusTeapot},
+		{method: http.MethodGet, path: "/openapi.yaml", status: http.StatusTeapot},
+		{method: http.MethodPost, path: "/openapi.json", status: http.StatusTeapot},
+	}
+	for _, tt := range testCases {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+		require.Equal(t, tt.status, rr.Code, "%s %s", tt.method, tt.path)
+		if tt.status == http.StatusOK {
+			var served map[string]any
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &served))
+			require.Equal(t, doc.OpenAPI, served["openapi"])
+		}
+	}
+}
+
+// TestSchemasMatchTypes keeps the schemas of the document in sync with the
+// types the handlers decode and render.
+func TestSchemasMatchTypes(t *testing.T) {
+	testCases := []struct {
+		schema string
+		value  any
+	}{
+		{schema: "Response", value: response.Response{}},
+		{schema: "CreateRequest", value: save.Request{}},
+		{schema: "CreateResponse", value: save.Response{}},
+		{schema: "ListResponse", value: list.Response{}},
+		{schema: "AuditResponse", value: audit.Response{}},
+		{schema: "ImportRow", value: linkfile.Row{}},
+		{schema: "ImportResponse", value: transfer.ImportResponse{}},
+		{schema: "StatsResponse", value: stats.Response{}},
+		{schema: "GroupStatsResponse", value: stats.GroupResponse{}},
+		{schema: "TagsRequest", value: tags.Request{}},
+		{schema: "UpdateRequest", value: update.Request{}},
+	}
+
+	doc, err := Load()
+	require.NoError(t, err)
+
+	for _, tt := range testCases {
+		t.Run(tt.schema, func(t *testing.T) {
+			schema, ok := doc.Components.Schemas[tt.schema]
+			require.True(t, ok, "schema %s is missing", tt.schema)
+			requireMatches(t, tt.schema, schema.Value, reflect.TypeOf(tt.value))
+		})
+	}
+}
+
+func requireMatches(t *testing.T, path string, schema *openapi3.Schema, typ reflect.Type) {
+	t.Helper()
+
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == reflect.TypeOf(time.Time{}):
+		require.True(t, schema.Type.Is(openapi3.TypeString), "%s is not a string", path)
+		require.Equal(t, "date-time", schema.Format, path)
+	case typ.Kind() == reflect.Struct:
+		properties, required := propertiesOf(schema)
+		fields := fieldsOf(typ)
+
+		var propertyNames, fieldNames []string
+		for name := range properties {
+			propertyNames = append(propertyNames, name)
+		}
+		for name := range fields {
+			fieldNames = append(fieldNames, name)
+		}
+		require.ElementsMatch(t, fieldNames, propertyNames, "properties of %s", path)
+
+		validated := false
+		for _, field := range fields {
+			validated = validated || field.Tag.Get("validate") != ""
+		}
+		for name, field := range fields {
+			requireMatches(t, path+"."+name, properties[name].Value, field.Type)
+			if validated {
+				// Conditions such as required_without_all are not required.
+				first, _, _ := strings.Cut(field.Tag.Get("validate"), ",")
+				isRequired := first == "required"
+				require.Equal(t, isRequired, required[name], "%s.%s is required", path, name)
+			}
+		}
+	case typ.Kind() == reflect.Slice:
+		require.True(t, schema.Type.Is(openapi3.TypeArray), "%s is not an array", path)
+		requireMatches(t, path+"[]", schema.Items.Value, typ.Elem())
+	case typ.Kind() == reflect.Map:
+		require.True(t, schema.Type.Is(openapi3.TypeObject), "%s is not an object", path)
+		require.NotNil(t, schema.AdditionalProperties.Schema, path)
+		requireMatches(t, path+"{}", schema.AdditionalProperties.Schema.Value, typ.Elem())
+	case typ.Kind() == reflect.String:
+		require.True(t, schema.Type.Is(openapi3.TypeString), "%s is not a string", path)
+	case typ.Kind() == reflect.Bool:
+		require.True(t, schema.Type.Is(openapi3.TypeBoolean), "%s is not a boolean", path)
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Uint64:
+		require.True(t, schema.Type.Is(openapi3.TypeInteger), "%s is not an integer", path)
+	default:
+		t.Fatalf("%s has a type without a schema: %s", path, typ)
+	}
+}
+
+// propertiesOf returns the properties of schema and whether they are
+// required, including those of the schemas of allOf.
+func propertiesOf(schema *openapi3.Schema) (map[string]*openapi3.SchemaRef, map[string]bool) {
+	properties := make(map[string]*openapi3.SchemaRef)
+	required := make(map[string]bool)
+
+	for _, sub := range schema.AllOf {
+		subProperties, subRequired := propertiesOf(sub.Value)
+		for name, property := range subProperties {
+			properties[name] = property
+		}
+		for name := range subRequired {
+			required[name] = true
+		}
+	}
+	for name, property := range schema.Properties {
+		properties[name] = property
+	}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	return properties, required
+}
+
+// fieldsOf returns the fields of typ by their JSON name, including those of
+// embedded structs.
+func fieldsOf(typ reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			for embeddedName, embedded := range fieldsOf(field.Type) {
+				fields[embeddedName] = embedded
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+
+	return fields
+}
@@ -37,8 +37,33 @@ func ValidationError(errs validator.ValidationErrors) Response {
 		switch err.ActualTag() {
 		case "required":
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
+		case "required_without_all":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s is required when none of %s is set",
+				err.Field(), strings.ReplaceAll(err.Param(), " ", ", ")))
 		case "url":
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid URL", err.Field()))
+		case "allowed_scheme":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s has a scheme that is not allowed", err.Field()))
+		case "allowed_domain":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s points to a domain that is not allowed", err.Field()))
+		case "public_network":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s points to a private network", err.Field()))
+		case "alias_charset":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s contains characters that are not allowed", err.Field()))
+		case "alias_length":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s has an invalid length", err.Field()))
+		case "alias_reserved":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s is reserved", err.Field()))
+		case "min":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be at least %s", err.Field(), err.Param()))
+		case "max":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be at most %s", err.Field(), err.Param()))
+		case "max_bytes":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be at most %s bytes long", err.Field(), err.Param()))
+		case "len":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be %s characters long", err.Field(), err.Param()))
+		case "oneof":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be one of %s", err.Field(), err.Param()))
 		default:
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
 		}
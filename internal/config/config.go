@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"sync"
 	"time"
@@ -10,21 +12,119 @@ import (
 )
 
 type Config struct {
-	Env         string `yaml:"env" env-required:"true"`
-	StoragePath string `yaml:"storage_path" env-required:"true"`
-	AliasLength int    `yaml:"alias_length" env-default:"6"`
-	HTTPServer  `yaml:"http_server"`
-	Clients     ClientsConfig `yaml:"clients"`
-	AppSecret   string        `yaml:"app_secret" env-required:"true" env:"APP_SECRET"`
+	Env          string `yaml:"env" env-required:"true"`
+	StoragePath  string `yaml:"storage_path" env-required:"true"`
+	GeoIPPath    string `yaml:"geoip_path" env:"GEOIP_PATH"`
+	AliasLength  int    `yaml:"alias_length" env-default:"6"`
+	RedirectType int    `yaml:"redirect_type" env-default:"302"`
+	// PublicBaseURL is the url short links are served at, e.g. https://sho.rt.
+	// Empty means the scheme and host of the request.
+	PublicBaseURL string `yaml:"public_base_url" env:"PUBLIC_BASE_URL"`
+	// DomainBaseURLs overrides https://{domain} for custom domains.
+	DomainBaseURLs map[string]string `yaml:"domain_base_urls"`
+	HTTPServer     `yaml:"http_server"`
+	Clients        ClientsConfig  `yaml:"clients"`
+	AppSecret      string         `yaml:"app_secret" env-required:"true" env:"APP_SECRET"`
+	Cache          Cache          `yaml:"cache"`
+	Redis          Redis          `yaml:"redis"`
+	URLPolicy      URLPolicy      `yaml:"url_policy"`
+	LinkChains     LinkChains     `yaml:"link_chains"`
+	AliasPolicy    AliasPolicy    `yaml:"alias_policy"`
+	LinkPassword   LinkPassword   `yaml:"link_password"`
+	ScheduledLinks ScheduledLinks `yaml:"scheduled_links"`
+	Trash          Trash          `yaml:"trash"`
+	Workspaces     []Workspace    `yaml:"workspaces"`
 }
 
 type HTTPServer struct {
-	Address         string        `yaml:"address" env-default:"localhost:8081"`
-	Timeout         time.Duration `yaml:"timeout" env-default:"4s"`
+	Address string        `yaml:"address" env-default:"localhost:8081"`
+	Timeout time.Duration `yaml:"timeout" env-default:"4s"`
+	// TransferTimeout replaces Timeout for imports and exports.
+	TransferTimeout time.Duration `yaml:"transfer_timeout" env-default:"10m"`
 	IdleTimeout     time.Duration `yaml:"idle_timeout" env-default:"60s"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"6s"`
-	User            string        `yaml:"user" env-required:"true"`
-	Password        string        `yaml:"password" env-required:"true"`
+	// TrustedProxies are the addresses or CIDR ranges of the proxies, e.g.
+	// load balancers, whose X-Forwarded-For header gives the client address.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	User           string   `yaml:"user" env-required:"true"`
+	Password       string   `yaml:"password" env-required:"true"`
+}
+
+type Cache struct {
+	Size        int           `yaml:"size" env-default:"10000"`
+	TTL         time.Duration `yaml:"ttl" env-default:"5m"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env-default:"30s"`
+}
+
+// Redis enables the shared cache when Address is set. It replaces the
+// in-process cache, which cannot be invalidated across replicas.
+type Redis struct {
+	Address     string        `yaml:"address" env:"REDIS_ADDRESS"`
+	Password    string        `yaml:"password" env:"REDIS_PASSWORD"`
+	DB          int           `yaml:"db" env-default:"0"`
+	Timeout     time.Duration `yaml:"timeout" env-default:"200ms"`
+	TTL         time.Duration `yaml:"ttl" env-default:"1h"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env-default:"30s"`
+}
+
+// URLPolicy restricts destination urls. With BlockPrivateNetworks a host
+// whose lookup fails or takes longer than LookupTimeout is refused, a name
+// that does not exist is let through.
+type URLPolicy struct {
+	AllowedSchemes       []string      `yaml:"allowed_schemes" env-default:"http,https"`
+	AllowedDomains       []string      `yaml:"allowed_domains"`
+	DeniedDomains        []string      `yaml:"denied_domains"`
+	BlockPrivateNetworks bool          `yaml:"block_private_networks" env-default:"true"`
+	LookupTimeout        time.Duration `yaml:"lookup_timeout" env-default:"2s"`
+}
+
+// LinkChains controls destinations which point back at the shortener.
+// The hosts of HTTPServer.Address, PublicBaseURL and DomainBaseURLs are always
+// treated as own hosts.
+type LinkChains struct {
+	OwnHosts []string `yaml:"own_hosts"`
+	MaxDepth int      `yaml:"max_depth" env-default:"3"`
+	Flatten  bool     `yaml:"flatten" env-default:"false"`
+}
+
+// AliasPolicy restricts custom aliases. Every top-level route is reserved
+// in addition to Reserved.
+type AliasPolicy struct {
+	Pattern         string   `yaml:"pattern" env-default:"^[a-zA-Z0-9_-]+$"`
+	MinLength       int      `yaml:"min_length" env-default:"3"`
+	MaxLength       int      `yaml:"max_length" env-default:"64"`
+	Reserved        []string `yaml:"reserved"`
+	CaseInsensitive bool     `yaml:"case_insensitive" env-default:"false"`
+}
+
+// LinkPassword limits failed password attempts per alias.
+type LinkPassword struct {
+	MaxAttempts int           `yaml:"max_attempts" env-default:"5"`
+	Window      time.Duration `yaml:"window" env-default:"15m"`
+}
+
+// ScheduledLinks answers 404 for links that are not active yet, with the
+// coming soon page when ComingSoon is set. ComingSoonTemplate replaces the
+// built-in page, it gets .Alias and .ActiveFrom.
+type ScheduledLinks struct {
+	ComingSoon         bool   `yaml:"coming_soon" env-default:"false"`
+	ComingSoonTemplate string `yaml:"coming_soon_template"`
+}
+
+// Trash keeps deleted links restorable, with their aliases taken, for Retention.
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Workspace scopes the admin endpoints of its Members, given as SSO emails.
+// With a Prefix its aliases get their own namespace under /{prefix}/, each
+// of its Domains serves a namespace of its own.
+type Workspace struct {
+	Name    string   `yaml:"name"`
+	Prefix  string   `yaml:"prefix"`
+	Members []string `yaml:"members"`
+	Domains []string `yaml:"domains"`
 }
 
 type Client struct {
@@ -37,6 +137,36 @@ type ClientsConfig struct {
 	SSO Client `yaml:"sso"`
 }
 
+// Domains returns the custom domains of all workspaces.
+func (c *Config) Domains() []string {
+	var domains []string
+	for _, w := range c.Workspaces {
+		domains = append(domains, w.Domains...)
+	}
+	return domains
+}
+
+// Prefixes returns the prefixes of the workspaces which have one.
+func (c *Config) Prefixes() []string {
+	var prefixes []string
+	for _, w := range c.Workspaces {
+		if w.Prefix != "" {
+			prefixes = append(prefixes, w.Prefix)
+		}
+	}
+	return prefixes
+}
+
+// OwnHosts returns the hosts the default namespace is served at, see
+// LinkChains: LinkChains.OwnHosts, HTTPServer.Address and PublicBaseURL.
+func (c *Config) OwnHosts() []string {
+	hosts := append([]string{c.Address}, c.LinkChains.OwnHosts...)
+	if c.PublicBaseURL != "" {
+		hosts = append(hosts, c.PublicBaseURL)
+	}
+	return hosts
+}
+
 var cfg *Config
 var once sync.Once
 
@@ -62,5 +192,28 @@ func MustLoad() *Config {
 		log.Fatalf("cannot read config: %v", err)
 	}
 
+	if err := cfg.validate(); err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+
 	return &cfg
 }
+
+// validate checks the values the struct tags cannot.
+func (c *Config) validate() error {
+	switch c.RedirectType {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return fmt.Errorf("redirect_type must be one of 301, 302, 303, 307 or 308, got %d", c.RedirectType)
+	}
+
+	if c.Trash.Retention <= 0 {
+		return fmt.Errorf("trash.retention must be positive, got %s", c.Trash.Retention)
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		return fmt.Errorf("trash.purge_interval must be positive, got %s", c.Trash.PurgeInterval)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() *Config {
+	return &Config{
+		RedirectType: 302,
+		Trash:        Trash{Retention: 720 * time.Hour, PurgeInterval: time.Hour},
+	}
+}
+
+func TestValidateRedirectType(t *testing.T) {
+	for _, redirectType := range []int{301, 302, 303, 307, 308} {
+		cfg := validConfig()
+		cfg.RedirectType = redirectType
+		require.NoError(t, cfg.validate())
+	}
+	for _, redirectType := range []int{0, 200, 304, 404} {
+		cfg := validConfig()
+		cfg.RedirectType = redirectType
+		require.Error(t, cfg.validate())
+	}
+}
+
+func TestValidateTrash(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Hour} {
+		cfg := validConfig()
+		cfg.Trash.Retention = d
+		require.Error(t, cfg.validate())
+
+		cfg = validConfig()
+		cfg.Trash.PurgeInterval = d
+		require.Error(t, cfg.validate())
+	}
+}
@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Event struct {
+	ID         int64     `json:"id"`
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	Alias      string    `json:"alias"`
+	OldValue   string    `json:"old_value,omitempty"`
+	NewValue   string    `json:"new_value,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Response struct {
+	resp.Response
+	Events []Event `json:"events,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2 --name=AuditLister
+type AuditLister interface {
+	ListAudit(filter storage.AuditFilter) ([]storage.AuditEvent, error)
+}
+
+var log *slog.Logger = sl.GetLogger()
+
+// ListAudit returns audit events filtered by the actor, action, alias,
+// since, until (RFC 3339) and limit query parameters, newest first.
+func ListAudit(auditLister AuditLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.audit.ListAudit"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+		ws := workspace.FromContext(r.Context())
+		filter := storage.AuditFilter{
+			Workspace: ws.Name,
+			Actor:     query.Get("actor"),
+			Action:    query.Get("action"),
+			Limit:     defaultLimit,
+		}
+		if alias := query.Get("alias"); alias != "" {
+			filter.Alias = ws.Alias(alias)
+		}
+
+		var err error
+		if since := query.Get("since"); since != "" {
+			if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+				log.Info("invalid since", sl.Err(err))
+				render.JSON(w, r, resp.Error("invalid since"))
+				return
+			}
+		}
+		if until := query.Get("until"); until != "" {
+			if filter.Until, err = time.Parse(time.RFC3339, until); err != nil {
+				log.Info("invalid until", sl.Err(err))
+				render.JSON(w, r, resp.Error("invalid until"))
+				return
+			}
+		}
+		if limit := query.Get("limit"); limit != "" {
+			filter.Limit, err = strconv.Atoi(limit)
+			if err != nil || filter.Limit < 1 || filter.Limit > maxLimit {
+				log.Info("invalid limit", slog.String("limit", limit))
+				render.JSON(w, r, resp.Error("invalid limit"))
+				return
+			}
+		}
+
+		events, err := auditLister.ListAudit(filter)
+		if err != nil {
+			log.Info("failed to list audit events", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		res := Response{Response: resp.OK(), Events: make([]Event, 0, len(events))}
+		for _, event := range events {
+			res.Events = append(res.Events, Event{
+				ID:         event.ID,
+				Actor:      event.Actor,
+				Action:     event.Action,
+				Alias:      ws.Unalias(event.Alias),
+				OldValue:   event.OldValue,
+				NewValue:   event.NewValue,
+				RequestID:  event.RequestID,
+				RemoteAddr: event.RemoteAddr,
+				CreatedAt:  event.CreatedAt,
+			})
+		}
+
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-shortener/internal/http-server/handlers/url/audit/mocks"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAuditHandler(t *testing.T) {
+	brand := workspace.Workspace{Name: "brand", Prefix: "brand"}
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		workspace workspace.Workspace
+		query     string
+		filter    *storage.AuditFilter
+		events    []storage.AuditEvent
+		mockError error
+		respError string
+		aliases   []string
+	}{
+		{
+			name:   "defaults",
+			filter: &storage.AuditFilter{Limit: defaultLimit},
+			events: []storage.AuditEvent{
+				{ID: 2, Action: storage.AuditActionDelete, Alias: "b"},
+				{ID: 1, Action: storage.AuditActionCreate, Alias: "a"},
+			},
+			aliases: []string{"b", "a"},
+		},
+		{
+			name:      "filters in a workspace",
+			workspace: brand,
+			query:     "?actor=ann@example.com&action=create&alias=sale&since=2024-05-01T00:00:00Z&limit=10",
+			filter: &storage.AuditFilter{
+				Workspace: "brand",
+				Actor:     "ann@example.com",
+				Action:    storage.AuditActionCreate,
+				Alias:     "brand/sale",
+				Since:     since,
+				Limit:     10,
+			},
+			events:  []storage.AuditEvent{{ID: 1, Action: storage.AuditActionCreate, Alias: "brand/sale"}},
+			aliases: []string{"sale"},
+		},
+		{
+			name:      "invalid since",
+			query:     "?since=yesterday",
+			respError: "invalid since",
+		},
+		{
+			name:      "invalid limit",
+			query:     "?limit=0",
+			respError: "invalid limit",
+		},
+		{
+			name:      "limit too large",
+			query:     "?limit=1001",
+			respError: "invalid limit",
+		},
+		{
+			name:      "ListAudit error",
+			filter:    &storage.AuditFilter{Limit: defaultLimit},
+			mockError: errors.New("unexpected error"),
+			respError: "internal error",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			auditListerMock := mocks.NewAuditLister(t)
+			if tt.filter != nil {
+				auditListerMock.On("ListAudit", *tt.filter).
+					Return(tt.events, tt.mockError).
+					Once()
+			}
+
+			handler := workspace.Set(tt.workspace)(ListAudit(auditListerMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/url/audit"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
+
+			var aliases []string
+			for _, event := range resp.Events {
+				aliases = append(aliases, event.Alias)
+			}
+			require.Equal(t, tt.aliases, aliases)
+		})
+	}
+}
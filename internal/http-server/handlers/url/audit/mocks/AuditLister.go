@@ -0,0 +1,58 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	storage "url-shortener/internal/storage"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditLister is an autogenerated mock type for the AuditLister type
+type AuditLister struct {
+	mock.Mock
+}
+
+// ListAudit provides a mock function with given fields: filter
+func (_m *AuditLister) ListAudit(filter storage.AuditFilter) ([]storage.AuditEvent, error) {
+	ret := _m.Called(filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAudit")
+	}
+
+	var r0 []storage.AuditEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(storage.AuditFilter) ([]storage.AuditEvent, error)); ok {
+		return rf(filter)
+	}
+	if rf, ok := ret.Get(0).(func(storage.AuditFilter) []storage.AuditEvent); ok {
+		r0 = rf(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.AuditEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(storage.AuditFilter) error); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewAuditLister creates a new instance of AuditLister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditLister(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditLister {
+	mock := &AuditLister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
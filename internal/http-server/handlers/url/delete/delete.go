@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"net/http"
 	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/audit"
 	"url-shortener/internal/storage"
 	sl "url-shortener/pkg/logger/slog"
 
@@ -15,7 +17,7 @@ import (
 
 //go:generate go run github.com/vektra/mockery/v2 --name=URLDeleter
 type URLDeleter interface {
-	DeleteURL(alias string) error
+	DeleteURL(alias string, events ...storage.AuditEvent) error
 }
 
 var log *slog.Logger = sl.GetLogger()
@@ -35,8 +37,10 @@ func DeleteURL(urlDeleter URLDeleter) http.HandlerFunc {
 			render.JSON(w, r, resp.Error("invalid request"))
 			return
 		}
+		alias = workspace.FromContext(r.Context()).Alias(alias)
 
-		err := urlDeleter.DeleteURL(alias)
+		// The url of the link is filled in by the storage.
+		err := urlDeleter.DeleteURL(alias, audit.Event(r, storage.AuditActionDelete, alias, "", ""))
 		if errors.Is(err, storage.ErrURLNotFound) {
 			log.Info("url for alias not found", slog.String("alias", alias))
 			render.JSON(w, r, resp.Error("not found"))
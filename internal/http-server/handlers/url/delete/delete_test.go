@@ -0,0 +1,82 @@
+package delete
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/handlers/url/delete/mocks"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteHandler(t *testing.T) {
+	testCases := []struct {
+		name      string
+		alias     string
+		workspace workspace.Workspace
+		key       string
+		respError string
+		mockError error
+	}{
+		{
+			name:  "success",
+			alias: "test",
+			key:   "test",
+		},
+		{
+			name:      "workspace",
+			alias:     "sale",
+			workspace: workspace.Workspace{Name: "brand", Prefix: "brand"},
+			key:       "brand/sale",
+		},
+		{
+			name:      "not found",
+			alias:     "test",
+			key:       "test",
+			respError: "not found",
+			mockError: storage.ErrURLNotFound,
+		},
+		{
+			name:      "DeleteURL error",
+			alias:     "test",
+			key:       "test",
+			respError: "internal error",
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlDeleterMock := mocks.NewURLDeleter(t)
+			urlDeleterMock.On("DeleteURL", tt.key, mock.MatchedBy(func(event storage.AuditEvent) bool {
+				return event.Action == storage.AuditActionDelete && event.Alias == tt.key &&
+					event.Workspace == tt.workspace.Name
+			})).
+				Return(tt.mockError).
+				Once()
+
+			r := chi.NewRouter()
+			r.Use(workspace.Set(tt.workspace))
+			r.Delete("/url/{alias}", DeleteURL(urlDeleterMock))
+
+			req := httptest.NewRequest(http.MethodDelete, "/url/"+tt.alias, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
+		})
+	}
+}
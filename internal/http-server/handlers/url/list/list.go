@@ -0,0 +1,111 @@
+package list
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Link struct {
+	Alias        string     `json:"alias"`
+	ShortURL     string     `json:"short_url"`
+	URL          string     `json:"url"`
+	RedirectType int        `json:"redirect_type,omitempty"`
+	Passthrough  bool       `json:"passthrough,omitempty"`
+	MaxClicks    int        `json:"max_clicks,omitempty"`
+	ActiveFrom   *time.Time `json:"active_from,omitempty"`
+	Folder       string     `json:"folder,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Owner        string     `json:"owner,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type Response struct {
+	resp.Response
+	Links []Link `json:"links,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2 --name=URLLister
+type URLLister interface {
+	ListURLs(filter storage.LinkFilter) ([]storage.Link, error)
+}
+
+var log *slog.Logger = sl.GetLogger()
+
+// ListURLs returns links filtered by the tag, folder and limit query
+// parameters, newest first.
+func ListURLs(urlLister URLLister, shortURLs *shorturl.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.list.ListURLs"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+		ws := workspace.FromContext(r.Context())
+		filter := storage.LinkFilter{
+			Workspace:    ws.Name,
+			Namespace:    ws.Namespace(),
+			OwnNamespace: true,
+			Tag:          query.Get("tag"),
+			Folder:       query.Get("folder"),
+			Limit:        defaultLimit,
+		}
+
+		if limit := query.Get("limit"); limit != "" {
+			var err error
+			filter.Limit, err = strconv.Atoi(limit)
+			if err != nil || filter.Limit < 1 || filter.Limit > maxLimit {
+				log.Info("invalid limit", slog.String("limit", limit))
+				render.JSON(w, r, resp.Error("invalid limit"))
+				return
+			}
+		}
+
+		links, err := urlLister.ListURLs(filter)
+		if err != nil {
+			log.Info("failed to list urls", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		res := Response{Response: resp.OK(), Links: make([]Link, 0, len(links))}
+		for _, link := range links {
+			item := Link{
+				Alias:        ws.Unalias(link.Alias),
+				ShortURL:     shortURLs.URL(r, link.Alias),
+				URL:          link.URL,
+				RedirectType: link.RedirectType,
+				Passthrough:  link.Passthrough,
+				MaxClicks:    link.MaxClicks,
+				Folder:       link.Folder,
+				Tags:         link.Tags,
+				Owner:        link.Owner,
+				CreatedAt:    link.CreatedAt,
+			}
+			if !link.ActiveFrom.IsZero() {
+				activeFrom := link.ActiveFrom
+				item.ActiveFrom = &activeFrom
+			}
+			res.Links = append(res.Links, item)
+		}
+
+		render.JSON(w, r, res)
+	}
+}
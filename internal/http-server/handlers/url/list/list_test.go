@@ -0,0 +1,116 @@
+package list
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-shortener/internal/http-server/handlers/url/list/mocks"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListHandler(t *testing.T) {
+	brand := workspace.Workspace{Name: "brand", Prefix: "brand"}
+	activeFrom := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		workspace  workspace.Workspace
+		query      string
+		filter     *storage.LinkFilter
+		links      []storage.Link
+		mockError  error
+		respError  string
+		aliases    []string
+		shortURLs  []string
+		activeFrom []*time.Time
+	}{
+		{
+			name:   "defaults",
+			filter: &storage.LinkFilter{OwnNamespace: true, Limit: defaultLimit},
+			links: []storage.Link{
+				{Alias: "b", URL: "https://ya.ru/b", ActiveFrom: activeFrom},
+				{Alias: "a", URL: "https://ya.ru/a"},
+			},
+			aliases:    []string{"b", "a"},
+			shortURLs:  []string{"https://sho.rt/b", "https://sho.rt/a"},
+			activeFrom: []*time.Time{&activeFrom, nil},
+		},
+		{
+			name:      "filters in a workspace",
+			workspace: brand,
+			query:     "?tag=ads&folder=spring&limit=10",
+			filter: &storage.LinkFilter{
+				Workspace:    "brand",
+				Namespace:    "brand",
+				OwnNamespace: true,
+				Tag:          "ads",
+				Folder:       "spring",
+				Limit:        10,
+			},
+			links:      []storage.Link{{Alias: "brand/sale", URL: "https://ya.ru", Workspace: "brand"}},
+			aliases:    []string{"sale"},
+			shortURLs:  []string{"https://sho.rt/brand/sale"},
+			activeFrom: []*time.Time{nil},
+		},
+		{
+			name:      "invalid limit",
+			query:     "?limit=abc",
+			respError: "invalid limit",
+		},
+		{
+			name:      "limit too large",
+			query:     "?limit=1001",
+			respError: "invalid limit",
+		},
+		{
+			name:      "ListURLs error",
+			filter:    &storage.LinkFilter{OwnNamespace: true, Limit: defaultLimit},
+			mockError: errors.New("unexpected error"),
+			respError: "internal error",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlListerMock := mocks.NewURLLister(t)
+			if tt.filter != nil {
+				urlListerMock.On("ListURLs", *tt.filter).
+					Return(tt.links, tt.mockError).
+					Once()
+			}
+
+			shortURLs := shorturl.New("https://sho.rt", nil, nil)
+			handler := workspace.Set(tt.workspace)(ListURLs(urlListerMock, shortURLs))
+
+			req := httptest.NewRequest(http.MethodGet, "/url"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
+
+			var aliases, urls []string
+			var activeFrom []*time.Time
+			for _, link := range resp.Links {
+				aliases = append(aliases, link.Alias)
+				urls = append(urls, link.ShortURL)
+				activeFrom = append(activeFrom, link.ActiveFrom)
+			}
+			require.Equal(t, tt.aliases, aliases)
+			require.Equal(t, tt.shortURLs, urls)
+			require.Equal(t, tt.activeFrom, activeFrom)
+		})
+	}
+}
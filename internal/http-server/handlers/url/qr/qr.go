@@ -0,0 +1,132 @@
+package qr
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/qrcode"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultSize   = 256
+	minSize       = 64
+	maxSize       = 2048
+	defaultMargin = 4
+	maxMargin     = 16
+	defaultLevel  = "M"
+)
+
+//go:generate go run github.com/vektra/mockery/v2 --name=URLGetter
+type URLGetter interface {
+	GetURL(alias string) (storage.Link, error)
+}
+
+var log *slog.Logger = sl.GetLogger()
+
+// QRCode returns a QR code of the short url of the alias. The format (png or
+// svg), size in pixels, level of error correction (L, M, Q or H) and margin
+// in modules are taken from the query.
+func QRCode(urlGetter URLGetter, shortURLs *shorturl.Builder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.qr.QRCode"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("failed to get alias from url", slog.String("url", r.URL.Path))
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		alias = workspace.FromContext(r.Context()).Alias(alias)
+
+		query := r.URL.Query()
+		format := query.Get("format")
+		if format == "" {
+			format = "png"
+		}
+		if format != "png" && format != "svg" {
+			log.Info("invalid format", slog.String("format", format))
+			render.JSON(w, r, resp.Error("invalid format"))
+			return
+		}
+		size, ok := intParam(query, "size", defaultSize, minSize, maxSize)
+		if !ok {
+			log.Info("invalid size", slog.String("size", query.Get("size")))
+			render.JSON(w, r, resp.Error("invalid size"))
+			return
+		}
+		margin, ok := intParam(query, "margin", defaultMargin, 0, maxMargin)
+		if !ok {
+			log.Info("invalid margin", slog.String("margin", query.Get("margin")))
+			render.JSON(w, r, resp.Error("invalid margin"))
+			return
+		}
+		level := query.Get("level")
+		if level == "" {
+			level = defaultLevel
+		}
+
+		_, err := urlGetter.GetURL(alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url for alias not found", slog.String("alias", alias))
+			render.JSON(w, r, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Info("failed to get url", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		code, err := qrcode.Encode(shortURLs.URL(r, alias), level, margin)
+		if err != nil {
+			log.Info("failed to encode qr code", sl.Err(err))
+			render.JSON(w, r, resp.Error("invalid level"))
+			return
+		}
+
+		switch format {
+		case "svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			err = code.SVG(w, size)
+		default:
+			w.Header().Set("Content-Type", "image/png")
+			err = code.PNG(w, size)
+		}
+		if errors.Is(err, qrcode.ErrTooSmall) {
+			log.Info("size is too small", slog.Int("size", size), slog.Int("modules", code.Modules()))
+			render.JSON(w, r, resp.Error("size is too small"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to write qr code", sl.Err(err))
+		}
+	}
+}
+
+func intParam(query url.Values, name string, def int, low int, high int) (int, bool) {
+	raw := query.Get(name)
+	if raw == "" {
+		return def, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < low || value > high {
+		return 0, false
+	}
+	return value, true
+}
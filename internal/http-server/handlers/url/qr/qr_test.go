@@ -0,0 +1,93 @@
+package qr
+
+import (
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/handlers/url/qr/mocks"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQRCodeHandler(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		contentType string
+		respError   string
+		mockError   error
+	}{
+		{
+			name:        "png",
+			contentType: "image/png",
+		},
+		{
+			name:        "svg",
+			query:       "?format=svg&size=512&level=H&margin=0",
+			contentType: "image/svg+xml",
+		},
+		{
+			name:      "invalid format",
+			query:     "?format=gif",
+			respError: "invalid format",
+		},
+		{
+			name:      "invalid size",
+			query:     "?size=10000",
+			respError: "invalid size",
+		},
+		{
+			name:      "invalid level",
+			query:     "?level=X",
+			respError: "invalid level",
+		},
+		{
+			name:      "not found",
+			respError: "not found",
+			mockError: storage.ErrURLNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: "https://ya.ru"}, tt.mockError).
+				Maybe()
+
+			r := chi.NewRouter()
+			r.Get("/url/{alias}/qr", QRCode(urlGetterMock, shorturl.New("https://sho.rt", nil, nil)))
+
+			req := httptest.NewRequest(http.MethodGet, "/url/test/qr"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			if tt.respError != "" {
+				var resp response.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, tt.respError, resp.Error)
+				return
+			}
+
+			require.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+			if tt.contentType == "image/png" {
+				img, err := png.Decode(rr.Body)
+				require.NoError(t, err)
+				require.LessOrEqual(t, img.Bounds().Dx(), defaultSize)
+			} else {
+				require.True(t, strings.HasPrefix(rr.Body.String(), "<svg"))
+			}
+		})
+	}
+}
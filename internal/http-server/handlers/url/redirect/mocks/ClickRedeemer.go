@@ -0,0 +1,80 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// ClickRedeemer is an autogenerated mock type for the ClickRedeemer type
+type ClickRedeemer struct {
+	mock.Mock
+}
+
+// RedeemClick provides a mock function with given fields: alias
+func (_m *ClickRedeemer) RedeemClick(alias string) (int, error) {
+	ret := _m.Called(alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RedeemClick")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (int, error)); ok {
+		return rf(alias)
+	}
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(alias)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemainingClicks provides a mock function with given fields: alias
+func (_m *ClickRedeemer) RemainingClicks(alias string) (int, error) {
+	ret := _m.Called(alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemainingClicks")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (int, error)); ok {
+		return rf(alias)
+	}
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(alias)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewClickRedeemer creates a new instance of ClickRedeemer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClickRedeemer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ClickRedeemer {
+	mock := &ClickRedeemer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
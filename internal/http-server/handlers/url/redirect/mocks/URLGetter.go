@@ -1,8 +1,12 @@
-// Code generated by mockery v2.45.0. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url-shortener/internal/storage"
+)
 
 // URLGetter is an autogenerated mock type for the URLGetter type
 type URLGetter struct {
@@ -10,22 +14,22 @@ type URLGetter struct {
 }
 
 // GetURL provides a mock function with given fields: alias
-func (_m *URLGetter) GetURL(alias string) (string, error) {
+func (_m *URLGetter) GetURL(alias string) (storage.Link, error) {
 	ret := _m.Called(alias)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetURL")
 	}
 
-	var r0 string
+	var r0 storage.Link
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (storage.Link, error)); ok {
 		return rf(alias)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	if rf, ok := ret.Get(0).(func(string) storage.Link); ok {
 		r0 = rf(alias)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(storage.Link)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
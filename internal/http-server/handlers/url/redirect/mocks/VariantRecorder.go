@@ -0,0 +1,42 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// VariantRecorder is an autogenerated mock type for the VariantRecorder type
+type VariantRecorder struct {
+	mock.Mock
+}
+
+// RecordVariant provides a mock function with given fields: alias, id
+func (_m *VariantRecorder) RecordVariant(alias string, id int64) error {
+	ret := _m.Called(alias, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordVariant")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
+		r0 = rf(alias, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewVariantRecorder creates a new instance of VariantRecorder. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewVariantRecorder(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *VariantRecorder {
+	mock := &VariantRecorder{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
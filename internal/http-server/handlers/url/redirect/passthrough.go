@@ -0,0 +1,50 @@
+package redirect
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// errPathEscapes is returned for an extra path leaving the path of the
+// destination, e.g. through "..".
+var errPathEscapes = errors.New("extra path escapes the destination path")
+
+// passthrough appends extraPath to the path of destination and merges query
+// into its query string. Parameters already present in destination win.
+func passthrough(destination string, extraPath string, query url.Values) (string, error) {
+	const caller = "handlers.url.redirect.passthrough"
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if extraPath = strings.TrimPrefix(extraPath, "/"); extraPath != "" {
+		base := strings.TrimSuffix(u.Path, "/")
+		joined := path.Clean(base + "/" + extraPath)
+		if joined != base && !strings.HasPrefix(joined, base+"/") {
+			return "", fmt.Errorf("%s: %w", caller, errPathEscapes)
+		}
+		if strings.HasSuffix(extraPath, "/") && joined != "/" {
+			joined += "/"
+		}
+		u.Path = joined
+		u.RawPath = ""
+	}
+
+	if len(query) > 0 {
+		merged := u.Query()
+		for key, values := range query {
+			if _, ok := merged[key]; ok {
+				continue
+			}
+			merged[key] = values
+		}
+		u.RawQuery = merged.Encode()
+	}
+
+	return u.String(), nil
+}
@@ -0,0 +1,93 @@
+package redirect
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/lib/ratelimit"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/render"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHeader lets API clients pass the password of a protected link.
+const PasswordHeader = "X-Link-Password"
+
+var passwordTemplate = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>/{{.Alias}}</title>
+</head>
+<body>
+	<h1>/{{.Alias}}</h1>
+	<p>This short link is protected by a password.</p>
+	{{if .Error}}<p><strong>{{.Error}}</strong></p>{{end}}
+	<form method="post">
+		<input type="password" name="password" autofocus required>
+		<button type="submit">Continue</button>
+	</form>
+</body>
+</html>
+`))
+
+type passwordData struct {
+	Alias string
+	Error string
+}
+
+// checkPassword reports whether the request carries the password of link.
+// Otherwise it has already responded, with the form for browsers or with an
+// error for API clients.
+func checkPassword(w http.ResponseWriter, r *http.Request, link storage.Link, limiter *ratelimit.Limiter) bool {
+	const caller = "handlers.url.redirect.checkPassword"
+
+	fromHeader := true
+	password := r.Header.Get(PasswordHeader)
+	if password == "" && r.Method == http.MethodPost {
+		fromHeader = false
+		password = r.PostFormValue("password")
+	}
+
+	if password == "" {
+		renderPasswordForm(w, link.Alias, "")
+		return false
+	}
+
+	if !limiter.Allow(link.Alias) {
+		log.Info("too many password attempts", slog.String("alias", link.Alias))
+		render.Status(r, http.StatusTooManyRequests)
+		render.JSON(w, r, resp.Error("too many attempts"))
+		return false
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)); err != nil {
+		log.Info("invalid password", slog.String("caller", caller), slog.String("alias", link.Alias))
+
+		if fromHeader {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("invalid password"))
+			return false
+		}
+		renderPasswordForm(w, link.Alias, "Invalid password")
+		return false
+	}
+	limiter.Release(link.Alias)
+
+	return true
+}
+
+func renderPasswordForm(w http.ResponseWriter, alias string, errMsg string) {
+	const caller = "handlers.url.redirect.renderPasswordForm"
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusUnauthorized)
+
+	if err := passwordTemplate.Execute(w, passwordData{Alias: alias, Error: errMsg}); err != nil {
+		log.Error("failed to render password form", slog.String("caller", caller), sl.Err(err))
+	}
+}
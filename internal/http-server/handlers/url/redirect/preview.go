@@ -0,0 +1,57 @@
+package redirect
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+	"url-shortener/internal/storage"
+)
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>/{{.Alias}}</title>
+</head>
+<body>
+	<h1>/{{.Alias}}</h1>
+	<p>This short link leads to:</p>
+	<p><code>{{.URL}}</code></p>
+	<dl>
+		<dt>Host</dt>
+		<dd>{{.Host}}</dd>
+		<dt>Created</dt>
+		<dd>{{if .CreatedAt}}{{.CreatedAt}}{{else}}unknown{{end}}</dd>
+		<dt>Owner</dt>
+		<dd>{{if .Owner}}{{.Owner}}{{else}}unknown{{end}}</dd>
+	</dl>
+	<a href="{{.URL}}">Continue</a>
+</body>
+</html>
+`))
+
+type previewData struct {
+	Alias     string
+	URL       string
+	Host      string
+	CreatedAt string
+	Owner     string
+}
+
+func renderPreview(w http.ResponseWriter, link storage.Link) error {
+	data := previewData{
+		Alias: link.Alias,
+		URL:   link.URL,
+		Owner: link.Owner,
+	}
+	if u, err := url.Parse(link.URL); err == nil {
+		data.Host = u.Host
+	}
+	if !link.CreatedAt.IsZero() {
+		data.CreatedAt = link.CreatedAt.UTC().Format(time.RFC1123)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return previewTemplate.Execute(w, data)
+}
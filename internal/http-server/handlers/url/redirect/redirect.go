@@ -3,8 +3,15 @@ package redirect
 import (
 	"errors"
 	"log/slog"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/middleware/clientip"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/ratelimit"
 	"url-shortener/internal/storage"
 	sl "url-shortener/pkg/logger/slog"
 
@@ -15,12 +22,42 @@ import (
 
 //go:generate go run github.com/vektra/mockery/v2 --name=URLGetter
 type URLGetter interface {
-	GetURL(alias string) (string, error)
+	GetURL(alias string) (storage.Link, error)
+}
+
+// CountryResolver is used for rules matching on country, it may be nil.
+type CountryResolver interface {
+	Country(ip net.IP) (string, error)
+}
+
+// VariantRecorder counts which destination of a split link was served.
+//
+//go:generate go run github.com/vektra/mockery/v2 --name=VariantRecorder
+type VariantRecorder interface {
+	RecordVariant(alias string, id int64) error
+}
+
+// ClickRedeemer uses up a click of a link with MaxClicks set.
+//
+//go:generate go run github.com/vektra/mockery/v2 --name=ClickRedeemer
+type ClickRedeemer interface {
+	RedeemClick(alias string) (int, error)
+	// RemainingClicks returns the clicks left without using one up.
+	RemainingClicks(alias string) (int, error)
 }
 
 var log *slog.Logger = sl.GetLogger()
 
-func Redirect(urlGetter URLGetter) http.HandlerFunc {
+func Redirect(
+	urlGetter URLGetter,
+	countryResolver CountryResolver,
+	variantRecorder VariantRecorder,
+	clickRedeemer ClickRedeemer,
+) http.HandlerFunc {
+	cfg := config.GetConfig()
+	limiter := ratelimit.New(cfg.LinkPassword.MaxAttempts, cfg.LinkPassword.Window)
+	comingSoon := loadComingSoonTemplate(cfg.ScheduledLinks.ComingSoonTemplate)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		const caller = "handlers.url.redirect.Redirect"
 
@@ -30,13 +67,28 @@ func Redirect(urlGetter URLGetter) http.HandlerFunc {
 		)
 
 		alias := chi.URLParam(r, "alias")
+		extraPath := chi.URLParam(r, "*")
+		if format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); extraPath != "" && format != "" {
+			// middleware.URLFormat strips the extension from the routed path.
+			extraPath += "." + format
+		}
+		query := r.URL.Query()
+		preview := query.Get("preview") == "1"
+		query.Del("preview")
+		if trimmed, ok := strings.CutSuffix(alias, "+"); ok {
+			alias = trimmed
+			preview = true
+		}
 		if alias == "" {
 			log.Info("failed to get alias from url", slog.String("url", r.URL.Path))
 			render.JSON(w, r, resp.Error("invalid request"))
 			return
 		}
+		ws := workspace.FromContext(r.Context())
+		path := ws.Path(alias)
+		alias = ws.Alias(alias)
 
-		destinationURL, err := urlGetter.GetURL(alias)
+		link, err := urlGetter.GetURL(alias)
 		if errors.Is(err, storage.ErrURLNotFound) {
 			log.Info("url for alias not found", slog.String("alias", alias))
 			render.JSON(w, r, resp.Error("not found"))
@@ -48,12 +100,145 @@ func Redirect(urlGetter URLGetter) http.HandlerFunc {
 			return
 		}
 
+		if time.Now().Before(link.ActiveFrom) {
+			log.Info("url for alias is not active yet", slog.String("alias", alias))
+			if !cfg.ScheduledLinks.ComingSoon {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("not found"))
+				return
+			}
+			if err = renderComingSoon(w, comingSoon, link); err != nil {
+				log.Error("failed to render coming soon page", sl.Err(err))
+			}
+			return
+		}
+
+		if !link.ExpiresAt.IsZero() && !time.Now().Before(link.ExpiresAt) {
+			log.Info("url for alias has expired", slog.String("alias", alias))
+			render.Status(r, http.StatusGone)
+			render.JSON(w, r, resp.Error("gone"))
+			return
+		}
+
+		if link.PasswordHash != "" && !checkPassword(w, r, link, limiter) {
+			return
+		}
+
+		if extraPath != "" && !link.Passthrough {
+			log.Info("alias does not pass paths through", slog.String("alias", alias))
+			render.JSON(w, r, resp.Error("not found"))
+			return
+		}
+
+		if preview {
+			if link.MaxClicks > 0 {
+				remaining, err := clickRedeemer.RemainingClicks(alias)
+				if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+					log.Error("failed to get remaining clicks", sl.Err(err))
+					render.JSON(w, r, resp.Error("internal error"))
+					return
+				}
+				if remaining == 0 {
+					log.Info("url for alias has no clicks left", slog.String("alias", alias))
+					render.Status(r, http.StatusGone)
+					render.JSON(w, r, resp.Error("gone"))
+					return
+				}
+			}
+
+			log.Info("rendering preview", slog.String("alias", alias))
+			if err = renderPreview(w, link); err != nil {
+				log.Error("failed to render preview", sl.Err(err))
+			}
+			return
+		}
+
+		destinationURL := link.URL
+		variant, fresh := -1, false
+		if ruleURL, ok := matchRule(link.Rules, r, func() string {
+			return country(countryResolver, r)
+		}); ok {
+			destinationURL = ruleURL
+		} else if len(link.Destinations) > 0 {
+			variant, fresh = pickVariant(r, alias, link.Destinations)
+			destinationURL = link.Destinations[variant].URL
+		}
+
+		if link.Passthrough {
+			destinationURL, err = passthrough(destinationURL, extraPath, query)
+			if errors.Is(err, errPathEscapes) {
+				log.Info("extra path escapes the destination", slog.String("alias", alias), slog.String("path", extraPath))
+				render.JSON(w, r, resp.Error("not found"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to pass request through", sl.Err(err))
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+		}
+
+		if link.MaxClicks > 0 {
+			remaining, err := clickRedeemer.RedeemClick(alias)
+			if errors.Is(err, storage.ErrURLExhausted) || errors.Is(err, storage.ErrURLNotFound) {
+				log.Info("url for alias has no clicks left", slog.String("alias", alias))
+				render.Status(r, http.StatusGone)
+				render.JSON(w, r, resp.Error("gone"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to redeem click", sl.Err(err))
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+			log.Info("click redeemed", slog.String("alias", alias), slog.Int("remaining", remaining))
+		}
+
+		// The variant is only counted once the redirect is sure to be sent.
+		if variant >= 0 {
+			if fresh {
+				setVariantCookie(w, alias, path, link.Destinations[variant].ID)
+			}
+
+			log.Info("split variant picked", slog.String("alias", alias), slog.Int("variant", variant))
+			if err = variantRecorder.RecordVariant(alias, link.Destinations[variant].ID); err != nil {
+				log.Error("failed to record variant", sl.Err(err))
+			}
+		}
+
+		redirectType := link.RedirectType
+		if redirectType == 0 {
+			redirectType = cfg.RedirectType
+		}
+		if r.Method == http.MethodPost {
+			// The password form must not be resubmitted to the destination.
+			redirectType = http.StatusSeeOther
+		}
+
 		log.Info(
 			"url for alias retrieved",
 			slog.String("url", destinationURL),
 			slog.String("alias", alias),
+			slog.Int("redirect_type", redirectType),
 		)
 
-		http.Redirect(w, r, destinationURL, http.StatusFound)
+		http.Redirect(w, r, destinationURL, redirectType)
 	}
 }
+
+func country(countryResolver CountryResolver, r *http.Request) string {
+	const caller = "handlers.url.redirect.country"
+
+	ip := clientip.IP(r)
+	if countryResolver == nil || ip == nil {
+		return ""
+	}
+
+	code, err := countryResolver.Country(ip)
+	if err != nil {
+		log.Warn("failed to look up country", slog.String("caller", caller), sl.Err(err))
+		return ""
+	}
+
+	return code
+}
@@ -1,13 +1,25 @@
 package redirect
 
 import (
+	"io"
+	"net"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 	"url-shortener/internal/api"
 	"url-shortener/internal/http-server/handlers/url/redirect/mocks"
+	"url-shortener/internal/http-server/middleware/clientip"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/storage"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestRedirectHandler(t *testing.T) {
@@ -33,12 +45,12 @@ func TestRedirectHandler(t *testing.T) {
 
 			if tt.respError == "" || tt.mockError != nil {
 				urlGetterMock.On("GetURL", tt.alias).
-					Return(tt.url, tt.mockError).
+					Return(storage.Link{Alias: tt.alias, URL: tt.url}, tt.mockError).
 					Once()
 			}
 
 			r := chi.NewRouter()
-			r.Get("/{alias}", Redirect(urlGetterMock))
+			r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
 
 			tc := httptest.NewServer(r)
 			defer tc.Close()
@@ -50,3 +62,746 @@ func TestRedirectHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectHandlerRedirectType(t *testing.T) {
+	testCases := []struct {
+		name         string
+		redirectType int
+		status       int
+	}{
+		{
+			name:   "default",
+			status: http.StatusFound,
+		},
+		{
+			name:         "permanent",
+			redirectType: http.StatusMovedPermanently,
+			status:       http.StatusMovedPermanently,
+		},
+		{
+			name:         "temporary",
+			redirectType: http.StatusTemporaryRedirect,
+			status:       http.StatusTemporaryRedirect,
+		},
+		{
+			name:         "permanent redirect",
+			redirectType: http.StatusPermanentRedirect,
+			status:       http.StatusPermanentRedirect,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: "https://ya.ru", RedirectType: tt.redirectType}, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.status, rr.Code)
+			require.Equal(t, "https://ya.ru", rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectHandlerPreview(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{
+			name: "plus suffix",
+			path: "/test+",
+		},
+		{
+			name: "query param",
+			path: "/test?preview=1",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: "https://ya.ru/page", Owner: "admin@gmail.com"}, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+
+			body := rr.Body.String()
+			require.Contains(t, body, `href="https://ya.ru/page"`)
+			require.Contains(t, body, "ya.ru")
+			require.Contains(t, body, "admin@gmail.com")
+		})
+	}
+}
+
+func TestRedirectHandlerWorkspacePrefix(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "eng/docs").
+		Return(storage.Link{Alias: "eng/docs", URL: "https://go.dev/doc"}, nil).
+		Once()
+
+	r := chi.NewRouter()
+	r.Route("/eng", func(r chi.Router) {
+		r.Use(workspace.Set(workspace.Workspace{Name: "eng", Prefix: "eng"}))
+		r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/eng/docs", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusFound, rr.Code)
+	require.Equal(t, "https://go.dev/doc", rr.Header().Get("Location"))
+}
+
+func TestRedirectHandlerCustomDomain(t *testing.T) {
+	directory := workspace.NewDirectory()
+	require.NoError(t, directory.Add("a", "", nil, []string{"go.a.com"}))
+	require.NoError(t, directory.Add("b", "", nil, []string{"go.b.com"}))
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "go.a.com/sale").
+		Return(storage.Link{Alias: "go.a.com/sale", URL: "https://a.com/sale"}, nil).
+		Once()
+	urlGetterMock.On("GetURL", "go.b.com/sale").
+		Return(storage.Link{Alias: "go.b.com/sale", URL: "https://b.com/sale"}, nil).
+		Once()
+
+	r := chi.NewRouter()
+	r.Use(workspace.Host(directory))
+	r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
+
+	for host, destination := range map[string]string{
+		"go.a.com":     "https://a.com/sale",
+		"go.b.com:443": "https://b.com/sale",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/sale", nil)
+		req.Host = host
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusFound, rr.Code)
+		require.Equal(t, destination, rr.Header().Get("Location"))
+	}
+}
+
+func TestRedirectHandlerPassthrough(t *testing.T) {
+	testCases := []struct {
+		name        string
+		path        string
+		url         string
+		passthrough bool
+		location    string
+		status      int
+	}{
+		{
+			name:        "path and query",
+			path:        "/test/extra/path?utm_source=x",
+			url:         "https://ya.ru/base?ref=short",
+			passthrough: true,
+			location:    "https://ya.ru/base/extra/path?ref=short&utm_source=x",
+			status:      http.StatusFound,
+		},
+		{
+			name:        "path with extension",
+			path:        "/test/docs/file.pdf",
+			url:         "https://ya.ru",
+			passthrough: true,
+			location:    "https://ya.ru/docs/file.pdf",
+			status:      http.StatusFound,
+		},
+		{
+			name:        "stored params win",
+			path:        "/test?ref=other",
+			url:         "https://ya.ru/?ref=short",
+			passthrough: true,
+			location:    "https://ya.ru/?ref=short",
+			status:      http.StatusFound,
+		},
+		{
+			name:     "disabled ignores query",
+			path:     "/test?utm_source=x",
+			url:      "https://ya.ru/base",
+			location: "https://ya.ru/base",
+			status:   http.StatusFound,
+		},
+		{
+			name:        "path in the destination path",
+			path:        "/test/docs/../file",
+			url:         "https://ya.ru/base",
+			passthrough: true,
+			location:    "https://ya.ru/base/file",
+			status:      http.StatusFound,
+		},
+		{
+			name:        "path escaping the destination path",
+			path:        "/test/../../x",
+			url:         "https://ya.ru/base/dir",
+			passthrough: true,
+			status:      http.StatusOK,
+		},
+		{
+			name:   "disabled rejects path",
+			path:   "/test/extra",
+			url:    "https://ya.ru/base",
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: tt.url, Passthrough: tt.passthrough}, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Use(middleware.URLFormat)
+			r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
+			r.Get("/{alias}/*", Redirect(urlGetterMock, nil, nil, nil))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.status, rr.Code)
+			require.Equal(t, tt.location, rr.Header().Get("Location"))
+		})
+	}
+}
+
+type countryStub map[string]string
+
+func (c countryStub) Country(ip net.IP) (string, error) {
+	return c[ip.String()], nil
+}
+
+func TestRedirectHandlerRules(t *testing.T) {
+	rules := []storage.Rule{
+		{Device: DeviceIOS, URL: "https://apps.apple.com/app"},
+		{Device: DeviceAndroid, URL: "https://play.google.com/app"},
+		{Country: "DE", Language: "de", URL: "https://ya.ru/de"},
+	}
+
+	testCases := []struct {
+		name       string
+		userAgent  string
+		language   string
+		remoteAddr string
+		forwarded  string
+		location   string
+	}{
+		{
+			name:      "ios",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+			location:  "https://apps.apple.com/app",
+		},
+		{
+			name:      "android",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8)",
+			location:  "https://play.google.com/app",
+		},
+		{
+			name:       "country and language",
+			userAgent:  "Mozilla/5.0 (X11; Linux x86_64)",
+			language:   "de-DE,de;q=0.9,en;q=0.8",
+			remoteAddr: "1.2.3.4:5555",
+			location:   "https://ya.ru/de",
+		},
+		{
+			name:       "language refused",
+			userAgent:  "Mozilla/5.0 (X11; Linux x86_64)",
+			language:   "en, de;q=0",
+			remoteAddr: "1.2.3.4:5555",
+			location:   "https://ya.ru",
+		},
+		{
+			name:       "other country",
+			userAgent:  "Mozilla/5.0 (X11; Linux x86_64)",
+			language:   "de",
+			remoteAddr: "5.6.7.8:5555",
+			location:   "https://ya.ru",
+		},
+		{
+			name:       "behind a trusted proxy",
+			userAgent:  "Mozilla/5.0 (X11; Linux x86_64)",
+			language:   "de",
+			remoteAddr: "10.0.0.1:5555",
+			forwarded:  "1.2.3.4",
+			location:   "https://ya.ru/de",
+		},
+		{
+			name:       "forwarded by an untrusted peer",
+			userAgent:  "Mozilla/5.0 (X11; Linux x86_64)",
+			language:   "de",
+			remoteAddr: "5.6.7.8:5555",
+			forwarded:  "1.2.3.4",
+			location:   "https://ya.ru",
+		},
+	}
+
+	resolveClientIP, err := clientip.Resolve([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: "https://ya.ru", Rules: rules}, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Use(resolveClientIP)
+			r.Get("/{alias}", Redirect(urlGetterMock, countryStub{"1.2.3.4": "DE", "5.6.7.8": "FR"}, nil, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			req.Header.Set("Accept-Language", tt.language)
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			if tt.forwarded != "" {
+				req.Header.Set(clientip.Header, tt.forwarded)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, tt.location, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectHandlerSplit(t *testing.T) {
+	destinations := []storage.Destination{
+		{ID: 7, URL: "https://ya.ru/a", Weight: 1},
+		{ID: 9, URL: "https://ya.ru/b", Weight: 1},
+	}
+
+	testCases := []struct {
+		name         string
+		destinations []storage.Destination
+		cookie       string
+		variant      int64
+		location     string
+	}{
+		{
+			name:         "sticky first",
+			destinations: destinations,
+			cookie:       "7",
+			variant:      7,
+			location:     "https://ya.ru/a",
+		},
+		{
+			name:         "sticky second",
+			destinations: destinations,
+			cookie:       "9",
+			variant:      9,
+			location:     "https://ya.ru/b",
+		},
+		{
+			name:         "sticky after reordering",
+			destinations: []storage.Destination{destinations[1], {ID: 11, URL: "https://ya.ru/c", Weight: 1}, destinations[0]},
+			cookie:       "7",
+			variant:      7,
+			location:     "https://ya.ru/a",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: "https://ya.ru", Destinations: tt.destinations}, nil).
+				Once()
+
+			variantRecorderMock := mocks.NewVariantRecorder(t)
+			variantRecorderMock.On("RecordVariant", "test", tt.variant).
+				Return(nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(urlGetterMock, nil, variantRecorderMock, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.AddCookie(&http.Cookie{Name: "variant_test", Value: tt.cookie})
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, tt.location, rr.Header().Get("Location"))
+		})
+	}
+
+	t.Run("new visitor", func(t *testing.T) {
+		t.Parallel()
+
+		urlGetterMock := mocks.NewURLGetter(t)
+		urlGetterMock.On("GetURL", "test").
+			Return(storage.Link{Alias: "test", URL: "https://ya.ru", Destinations: destinations}, nil).
+			Once()
+
+		variantRecorderMock := mocks.NewVariantRecorder(t)
+		variantRecorderMock.On("RecordVariant", "test", mock.AnythingOfType("int64")).
+			Return(nil).
+			Once()
+
+		r := chi.NewRouter()
+		r.Get("/{alias}", Redirect(urlGetterMock, nil, variantRecorderMock, nil))
+
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		cookies := rr.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, "variant_test", cookies[0].Name)
+
+		location := map[string]string{"7": "https://ya.ru/a", "9": "https://ya.ru/b"}
+		require.Equal(t, location[cookies[0].Value], rr.Header().Get("Location"))
+	})
+}
+
+func TestWeightedRandom(t *testing.T) {
+	destinations := []storage.Destination{
+		{URL: "https://ya.ru/a", Weight: 0},
+		{URL: "https://ya.ru/b", Weight: 3},
+	}
+
+	for range 100 {
+		require.Equal(t, 1, weightedRandom(destinations))
+	}
+}
+
+func TestRedirectHandlerPassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		method   string
+		header   string
+		form     string
+		status   int
+		location string
+	}{
+		{
+			name:   "form",
+			method: http.MethodGet,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:     "header",
+			method:   http.MethodGet,
+			header:   "secret-password",
+			status:   http.StatusFound,
+			location: "https://ya.ru",
+		},
+		{
+			name:   "wrong header",
+			method: http.MethodGet,
+			header: "wrong",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:     "form submit",
+			method:   http.MethodPost,
+			form:     "secret-password",
+			status:   http.StatusSeeOther,
+			location: "https://ya.ru",
+		},
+		{
+			name:   "wrong form submit",
+			method: http.MethodPost,
+			form:   "wrong",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: "https://ya.ru", PasswordHash: string(hash)}, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
+			r.Post("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
+
+			var body io.Reader
+			if tt.form != "" {
+				body = strings.NewReader(url.Values{"password": {tt.form}}.Encode())
+			}
+			req := httptest.NewRequest(tt.method, "/test", body)
+			if tt.form != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			if tt.header != "" {
+				req.Header.Set(PasswordHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.status, rr.Code)
+			require.Equal(t, tt.location, rr.Header().Get("Location"))
+		})
+	}
+
+	t.Run("too many attempts", func(t *testing.T) {
+		t.Parallel()
+
+		urlGetterMock := mocks.NewURLGetter(t)
+		urlGetterMock.On("GetURL", "test").
+			Return(storage.Link{Alias: "test", URL: "https://ya.ru", PasswordHash: string(hash)}, nil)
+
+		r := chi.NewRouter()
+		r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
+
+		status := 0
+		for range 10 {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set(PasswordHeader, "wrong")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			status = rr.Code
+		}
+		require.Equal(t, http.StatusTooManyRequests, status)
+	})
+}
+
+func TestRedirectHandlerMaxClicks(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mockError error
+		status    int
+	}{
+		{
+			name:   "click left",
+			status: http.StatusFound,
+		},
+		{
+			name:      "exhausted",
+			mockError: storage.ErrURLExhausted,
+			status:    http.StatusGone,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: "https://ya.ru", MaxClicks: 1}, nil).
+				Once()
+
+			clickRedeemerMock := mocks.NewClickRedeemer(t)
+			clickRedeemerMock.On("RedeemClick", "test").
+				Return(0, tt.mockError).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, clickRedeemerMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.status, rr.Code)
+		})
+	}
+}
+
+func TestRedirectHandlerMaxClicksPreview(t *testing.T) {
+	testCases := []struct {
+		name      string
+		remaining int
+		status    int
+	}{
+		{
+			name:      "click left",
+			remaining: 1,
+			status:    http.StatusOK,
+		},
+		{
+			name:   "used up",
+			status: http.StatusGone,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: "https://ya.ru/page", MaxClicks: 1}, nil).
+				Once()
+
+			clickRedeemerMock := mocks.NewClickRedeemer(t)
+			clickRedeemerMock.On("RemainingClicks", "test").
+				Return(tt.remaining, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, clickRedeemerMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/test+", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.status, rr.Code)
+			if tt.status == http.StatusGone {
+				require.NotContains(t, rr.Body.String(), "https://ya.ru/page")
+			}
+		})
+	}
+}
+
+func TestRedirectHandlerMaxClicksSplit(t *testing.T) {
+	t.Parallel()
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "test").
+		Return(storage.Link{
+			Alias:        "test",
+			URL:          "https://ya.ru",
+			MaxClicks:    1,
+			Destinations: []storage.Destination{{URL: "https://ya.ru/a", Weight: 1}},
+		}, nil).
+		Once()
+
+	clickRedeemerMock := mocks.NewClickRedeemer(t)
+	clickRedeemerMock.On("RedeemClick", "test").
+		Return(0, storage.ErrURLExhausted).
+		Once()
+
+	// The mock fails the test if a used up link is counted as a hit.
+	variantRecorderMock := mocks.NewVariantRecorder(t)
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", Redirect(urlGetterMock, nil, variantRecorderMock, clickRedeemerMock))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusGone, rr.Code)
+	require.Empty(t, rr.Result().Cookies())
+}
+
+func TestRedirectHandlerActiveFrom(t *testing.T) {
+	testCases := []struct {
+		name       string
+		activeFrom time.Time
+		status     int
+	}{
+		{
+			name:       "active",
+			activeFrom: time.Now().Add(-time.Hour),
+			status:     http.StatusFound,
+		},
+		{
+			name:       "not active yet",
+			activeFrom: time.Now().Add(time.Hour),
+			status:     http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: "https://ya.ru", ActiveFrom: tt.activeFrom}, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.status, rr.Code)
+		})
+	}
+}
+
+func TestRedirectHandlerExpiresAt(t *testing.T) {
+	testCases := []struct {
+		name      string
+		expiresAt time.Time
+		url       string
+		status    int
+	}{
+		{
+			name:      "not expired",
+			expiresAt: time.Now().Add(time.Hour),
+			url:       "/test",
+			status:    http.StatusFound,
+		},
+		{
+			name:      "expired",
+			expiresAt: time.Now().Add(-time.Hour),
+			url:       "/test",
+			status:    http.StatusGone,
+		},
+		{
+			name:      "preview of expired",
+			expiresAt: time.Now().Add(-time.Hour),
+			url:       "/test?preview=1",
+			status:    http.StatusGone,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").
+				Return(storage.Link{Alias: "test", URL: "https://ya.ru", ExpiresAt: tt.expiresAt}, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", Redirect(urlGetterMock, nil, nil, nil))
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.status, rr.Code)
+		})
+	}
+}
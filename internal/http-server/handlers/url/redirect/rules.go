@@ -0,0 +1,87 @@
+package redirect
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"url-shortener/internal/storage"
+)
+
+const (
+	DeviceIOS     = "ios"
+	DeviceAndroid = "android"
+	DeviceDesktop = "desktop"
+)
+
+// matchRule returns the destination of the first rule matching r.
+// The country is only looked up if some rule needs it.
+func matchRule(rules []storage.Rule, r *http.Request, country func() string) (string, bool) {
+	device := deviceOf(r.UserAgent())
+	languages := languagesOf(r.Header.Get("Accept-Language"))
+
+	var countryCode string
+	var countryLooked bool
+
+	for _, rule := range rules {
+		if rule.Device != "" && rule.Device != device {
+			continue
+		}
+		if rule.Language != "" && !acceptsLanguage(languages, rule.Language) {
+			continue
+		}
+		if rule.Country != "" {
+			if !countryLooked {
+				countryCode, countryLooked = country(), true
+			}
+			if !strings.EqualFold(rule.Country, countryCode) {
+				continue
+			}
+		}
+		return rule.URL, true
+	}
+
+	return "", false
+}
+
+func deviceOf(userAgent string) string {
+	switch {
+	case strings.Contains(userAgent, "iPhone"),
+		strings.Contains(userAgent, "iPad"),
+		strings.Contains(userAgent, "iPod"):
+		return DeviceIOS
+	case strings.Contains(userAgent, "Android"):
+		return DeviceAndroid
+	default:
+		return DeviceDesktop
+	}
+}
+
+// languagesOf returns the language tags of an Accept-Language header,
+// ignoring the ones explicitly refused with q=0.
+func languagesOf(header string) []string {
+	var languages []string
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		languages = append(languages, strings.ToLower(tag))
+	}
+	return languages
+}
+
+// acceptsLanguage matches language as a prefix, so de matches de-AT.
+func acceptsLanguage(languages []string, language string) bool {
+	language = strings.ToLower(language)
+	for _, tag := range languages {
+		if tag == language || strings.HasPrefix(tag, language+"-") {
+			return true
+		}
+	}
+	return false
+}
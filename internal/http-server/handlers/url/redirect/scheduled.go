@@ -0,0 +1,57 @@
+package redirect
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+)
+
+var comingSoonTemplate = template.Must(template.New("coming-soon").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>/{{.Alias}}</title>
+</head>
+<body>
+	<h1>Coming soon</h1>
+	<p>This link will be available from {{.ActiveFrom}}.</p>
+</body>
+</html>
+`))
+
+type comingSoonData struct {
+	Alias      string
+	ActiveFrom string
+}
+
+// loadComingSoonTemplate returns the page configured for links that are not
+// active yet, falling back to the built-in one.
+func loadComingSoonTemplate(path string) *template.Template {
+	const caller = "handlers.url.redirect.loadComingSoonTemplate"
+
+	if path == "" {
+		return comingSoonTemplate
+	}
+
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		log.Error("failed to load coming soon template", slog.String("caller", caller), sl.Err(err))
+		return comingSoonTemplate
+	}
+
+	return tmpl
+}
+
+func renderComingSoon(w http.ResponseWriter, tmpl *template.Template, link storage.Link) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusNotFound)
+
+	return tmpl.Execute(w, comingSoonData{
+		Alias:      link.Alias,
+		ActiveFrom: link.ActiveFrom.UTC().Format(time.RFC1123),
+	})
+}
@@ -0,0 +1,71 @@
+package redirect
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/internal/storage"
+)
+
+const (
+	variantCookiePrefix = "variant_"
+	variantCookieMaxAge = 30 * 24 * time.Hour
+)
+
+// pickVariant returns the position of the destination served to the visitor
+// and whether it was newly picked. The choice is kept in a cookie by the ID
+// of the destination, see setVariantCookie, so that returning visitors get
+// the same one even if the destinations were reordered. A visitor whose
+// destination was removed gets a new one.
+func pickVariant(r *http.Request, alias string, destinations []storage.Destination) (int, bool) {
+	if cookie, err := r.Cookie(variantCookieName(alias)); err == nil {
+		if id, err := strconv.ParseInt(cookie.Value, 10, 64); err == nil {
+			for position, destination := range destinations {
+				if destination.ID == id {
+					return position, false
+				}
+			}
+		}
+	}
+
+	return weightedRandom(destinations), true
+}
+
+func setVariantCookie(w http.ResponseWriter, alias string, path string, id int64) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     variantCookieName(alias),
+		Value:    strconv.FormatInt(id, 10),
+		Path:     path,
+		MaxAge:   int(variantCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// variantCookieName replaces the slash of aliases in a namespace,
+// which cookie names may not contain.
+func variantCookieName(alias string) string {
+	return variantCookiePrefix + strings.ReplaceAll(alias, "/", ".")
+}
+
+func weightedRandom(destinations []storage.Destination) int {
+	total := 0
+	for _, destination := range destinations {
+		total += max(destination.Weight, 0)
+	}
+	if total == 0 {
+		return rand.Intn(len(destinations))
+	}
+
+	n := rand.Intn(total)
+	for i, destination := range destinations {
+		n -= max(destination.Weight, 0)
+		if n < 0 {
+			return i
+		}
+	}
+
+	return len(destinations) - 1
+}
@@ -0,0 +1,53 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url-shortener/internal/storage"
+)
+
+// URLRestorer is an autogenerated mock type for the URLRestorer type
+type URLRestorer struct {
+	mock.Mock
+}
+
+// RestoreURL provides a mock function with given fields: alias, events
+func (_m *URLRestorer) RestoreURL(alias string, events ...storage.AuditEvent) error {
+	_va := make([]interface{}, len(events))
+	for _i := range events {
+		_va[_i] = events[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, alias)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreURL")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, ...storage.AuditEvent) error); ok {
+		r0 = rf(alias, events...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewURLRestorer creates a new instance of URLRestorer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLRestorer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLRestorer {
+	mock := &URLRestorer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
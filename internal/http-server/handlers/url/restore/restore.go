@@ -0,0 +1,62 @@
+package restore
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+//go:generate go run github.com/vektra/mockery/v2 --name=URLRestorer
+type URLRestorer interface {
+	RestoreURL(alias string, events ...storage.AuditEvent) error
+}
+
+var log *slog.Logger = sl.GetLogger()
+
+func RestoreURL(urlRestorer URLRestorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.restore.RestoreURL"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("failed to get alias from url", slog.String("url", r.URL.Path))
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		alias = workspace.FromContext(r.Context()).Alias(alias)
+
+		// The url of the link is filled in by the storage.
+		err := urlRestorer.RestoreURL(alias, audit.Event(r, storage.AuditActionRestore, alias, "", ""))
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("deleted url for alias not found", slog.String("alias", alias))
+			render.JSON(w, r, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Info("failed to restore url", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info(
+			"url for alias restored",
+			slog.String("alias", alias),
+		)
+
+		render.JSON(w, r, resp.OK())
+	}
+}
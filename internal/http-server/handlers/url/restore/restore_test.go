@@ -0,0 +1,68 @@
+package restore
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/handlers/url/restore/mocks"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreHandler(t *testing.T) {
+	testCases := []struct {
+		name      string
+		alias     string
+		respError string
+		mockError error
+	}{
+		{
+			name:  "success",
+			alias: "test",
+		},
+		{
+			name:      "not deleted",
+			alias:     "test",
+			respError: "not found",
+			mockError: storage.ErrURLNotFound,
+		},
+		{
+			name:      "RestoreURL error",
+			alias:     "test",
+			respError: "internal error",
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlRestorerMock := mocks.NewURLRestorer(t)
+			urlRestorerMock.On("RestoreURL", tt.alias, mock.MatchedBy(func(event storage.AuditEvent) bool {
+				return event.Action == storage.AuditActionRestore && event.Alias == tt.alias
+			})).
+				Return(tt.mockError).
+				Once()
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/restore", RestoreURL(urlRestorerMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/url/"+tt.alias+"/restore", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package save
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// QueryConflictError is returned when a query parameter is set twice.
+type QueryConflictError struct {
+	Key string
+}
+
+func (e *QueryConflictError) Error() string {
+	return fmt.Sprintf("query parameter %s is already set", e.Key)
+}
+
+type UTM struct {
+	Source   string `json:"source,omitempty"`
+	Medium   string `json:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty"`
+	Term     string `json:"term,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+// params returns the parameters of u which are set, in a fixed order.
+func (u *UTM) params() [][2]string {
+	if u == nil {
+		return nil
+	}
+
+	var params [][2]string
+	for _, param := range [][2]string{
+		{"utm_source", u.Source},
+		{"utm_medium", u.Medium},
+		{"utm_campaign", u.Campaign},
+		{"utm_term", u.Term},
+		{"utm_content", u.Content},
+	} {
+		if param[1] != "" {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// withQuery adds the utm and then the query parameters, by key, to
+// destination. Its own query is kept as is. A parameter which is already
+// present in destination, or given twice, is a conflict.
+func withQuery(destination string, utm *UTM, query map[string]string) (string, error) {
+	const caller = "handlers.url.save.withQuery"
+
+	if utm == nil && len(query) == 0 {
+		return destination, nil
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", caller, err)
+	}
+
+	params := utm.params()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		params = append(params, [2]string{key, query[key]})
+	}
+
+	values := u.Query()
+	rawQuery := u.RawQuery
+	for _, param := range params {
+		key, value := param[0], param[1]
+		if values.Has(key) {
+			return "", fmt.Errorf("%s: %w", caller, &QueryConflictError{Key: key})
+		}
+		values.Set(key, value)
+
+		if rawQuery != "" {
+			rawQuery += "&"
+		}
+		rawQuery += url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	}
+	u.RawQuery = rawQuery
+
+	return u.String(), nil
+}
@@ -0,0 +1,78 @@
+package save
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithQuery(t *testing.T) {
+	testCases := []struct {
+		name        string
+		destination string
+		utm         *UTM
+		query       map[string]string
+		result      string
+		conflict    string
+	}{
+		{
+			name:        "nothing to add",
+			destination: "https://ya.ru/?b=2&a=1",
+			result:      "https://ya.ru/?b=2&a=1",
+		},
+		{
+			name:        "utm",
+			destination: "https://ya.ru/page",
+			utm:         &UTM{Source: "poster", Medium: "print", Campaign: "launch"},
+			result:      "https://ya.ru/page?utm_source=poster&utm_medium=print&utm_campaign=launch",
+		},
+		{
+			name:        "utm and query",
+			destination: "https://ya.ru/page?lang=en",
+			utm:         &UTM{Source: "poster"},
+			query:       map[string]string{"ref": "x y"},
+			result:      "https://ya.ru/page?lang=en&utm_source=poster&ref=x+y",
+		},
+		{
+			name:        "destination query kept as is",
+			destination: "https://ya.ru/search?q=a%2Cb&page=2&sig=abc%3D#top",
+			query:       map[string]string{"b": "2", "a": "1"},
+			result:      "https://ya.ru/search?q=a%2Cb&page=2&sig=abc%3D&a=1&b=2#top",
+		},
+		{
+			name:        "conflict with destination",
+			destination: "https://ya.ru/page?utm_source=mail",
+			utm:         &UTM{Source: "poster"},
+			conflict:    "utm_source",
+		},
+		{
+			name:        "conflict between utm and query",
+			destination: "https://ya.ru/page",
+			utm:         &UTM{Source: "poster"},
+			query:       map[string]string{"utm_source": "mail"},
+			conflict:    "utm_source",
+		},
+		{
+			name:        "first conflict by key",
+			destination: "https://ya.ru/page?a=1&b=2&c=3&d=4",
+			query:       map[string]string{"d": "x", "c": "x", "b": "x", "a": "x"},
+			conflict:    "a",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := withQuery(tt.destination, tt.utm, tt.query)
+			if tt.conflict != "" {
+				var conflictErr *QueryConflictError
+				require.ErrorAs(t, err, &conflictErr)
+				require.Equal(t, tt.conflict, conflictErr.Key)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.result, result)
+		})
+	}
+}
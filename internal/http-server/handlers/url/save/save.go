@@ -5,36 +5,79 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 	resp "url-shortener/internal/api/response"
 	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/middleware/sso"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/linkchain"
 	"url-shortener/internal/lib/random"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/lib/validation"
 	"url-shortener/internal/storage"
 	sl "url-shortener/pkg/logger/slog"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// Request creates a link. UTM and Query are merged into URL before it is
+// stored, Destinations split the visitors between weighted urls.
 type Request struct {
-	URL   string `json:"url" validate:"required,url"`
-	Alias string `json:"alias,omitempty"`
+	URL          string            `json:"url" validate:"required,url,allowed_scheme,allowed_domain,public_network"`
+	Alias        string            `json:"alias,omitempty" validate:"omitempty,alias_charset,alias_length,alias_reserved"`
+	RedirectType int               `json:"redirect_type,omitempty" validate:"omitempty,oneof=301 302 303 307 308"`
+	Passthrough  bool              `json:"passthrough,omitempty"`
+	UTM          *UTM              `json:"utm,omitempty"`
+	Query        map[string]string `json:"query,omitempty" validate:"omitempty,dive,keys,required,endkeys"`
+	Rules        []Rule            `json:"rules,omitempty" validate:"omitempty,dive"`
+	Destinations []Destination     `json:"destinations,omitempty" validate:"omitempty,min=2,dive"`
+	Password     string            `json:"password,omitempty" validate:"omitempty,min=8,max_bytes=72"`
+	MaxClicks    int               `json:"max_clicks,omitempty" validate:"omitempty,min=1"`
+	ActiveFrom   *time.Time        `json:"active_from,omitempty"`
+	Folder       string            `json:"folder,omitempty" validate:"omitempty,max=128"`
+	Tags         []string          `json:"tags,omitempty" validate:"omitempty,max=32,dive,required,max=64"`
+}
+
+type Destination struct {
+	URL    string `json:"url" validate:"required,url,allowed_scheme,allowed_domain,public_network"`
+	Weight int    `json:"weight" validate:"required,min=1"`
+}
+
+// Rule is a targeted destination, see storage.Rule. It needs a condition,
+// a rule without any would replace the url for every visitor.
+type Rule struct {
+	Device   string `json:"device,omitempty" validate:"required_without_all=Language Country,omitempty,oneof=ios android desktop"`
+	Language string `json:"language,omitempty"`
+	Country  string `json:"country,omitempty" validate:"omitempty,len=2,alpha"`
+	URL      string `json:"url" validate:"required,url,allowed_scheme,allowed_domain,public_network"`
 }
 
 type Response struct {
 	resp.Response
-	Alias string `json:"alias,omitempty"`
+	Alias    string `json:"alias,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
 }
 
 var log *slog.Logger = sl.GetLogger()
 
 //go:generate go run github.com/vektra/mockery/v2 --name=URLSaver
 type URLSaver interface {
-	SaveURL(urlToSave string, alias string) error
+	SaveURL(link storage.Link, events ...storage.AuditEvent) error
 }
 
-func NewURL(urlSaver URLSaver) http.HandlerFunc {
+func NewURL(
+	urlSaver URLSaver,
+	chains *linkchain.Chains,
+	validate *validation.Validator,
+	shortURLs *shorturl.Builder,
+) http.HandlerFunc {
 	cfg := config.GetConfig()
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		const caller = "handlers.url.save.NewURL"
 
@@ -57,9 +100,9 @@ func NewURL(urlSaver URLSaver) http.HandlerFunc {
 			return
 		}
 
-		log.Info("request body decoded", slog.Any("request", req))
+		log.Info("request body decoded", slog.Any("request", redacted(req)))
 
-		if err = validator.New().Struct(req); err != nil {
+		if err = validate.Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
 			log.Error("invalid request", sl.Err(err))
 			render.JSON(w, r, resp.ValidationError(validateErr))
@@ -70,10 +113,75 @@ func NewURL(urlSaver URLSaver) http.HandlerFunc {
 		if alias == "" {
 			alias = random.NewRandomString(cfg.AliasLength)
 		}
+		ws := workspace.FromContext(r.Context())
+		alias = ws.Alias(alias)
+
+		link := storage.Link{
+			Alias:        alias,
+			URL:          req.URL,
+			RedirectType: req.RedirectType,
+			Passthrough:  req.Passthrough,
+			Rules:        rules(req.Rules),
+			Destinations: destinations(req.Destinations),
+			MaxClicks:    req.MaxClicks,
+			ActiveFrom:   activeFrom(req.ActiveFrom),
+			Workspace:    ws.Name,
+			Folder:       req.Folder,
+			Tags:         req.Tags,
+			Owner:        sso.Email(r.Context()),
+		}
 
-		err = urlSaver.SaveURL(req.URL, alias)
+		err = chains.Resolve(&link)
+		if errors.Is(err, linkchain.ErrRedirectLoop) {
+			log.Info("url creates a redirect loop", slog.String("url", req.URL), sl.Err(err))
+			render.JSON(w, r, resp.Error("url creates a redirect loop"))
+			return
+		}
+		if errors.Is(err, linkchain.ErrChainTooLong) {
+			log.Info("url redirect chain is too long", slog.String("url", req.URL), sl.Err(err))
+			render.JSON(w, r, resp.Error("url redirect chain is too long"))
+			return
+		}
+		if errors.Is(err, linkchain.ErrUnknownTarget) {
+			log.Info("url points to an unknown alias", slog.String("url", req.URL), sl.Err(err))
+			render.JSON(w, r, resp.Error("url points to an unknown alias"))
+			return
+		}
+		if err != nil {
+			log.Info("failed to resolve redirect chain", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to add url"))
+			return
+		}
+
+		// The query is merged into the final destination, which replaces the
+		// url when chains are flattened.
+		destination, err := withQuery(link.URL, req.UTM, req.Query)
+		var conflictErr *QueryConflictError
+		if errors.As(err, &conflictErr) {
+			log.Info("query parameter conflict", slog.String("url", link.URL), sl.Err(err))
+			render.JSON(w, r, resp.Error(conflictErr.Error()))
+			return
+		}
+		if err != nil {
+			log.Info("failed to add query parameters", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to add url"))
+			return
+		}
+		link.URL = destination
+
+		if req.Password != "" {
+			passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+			if err != nil {
+				log.Error("failed to hash password", sl.Err(err))
+				render.JSON(w, r, resp.Error("failed to add url"))
+				return
+			}
+			link.PasswordHash = string(passwordHash)
+		}
+
+		err = urlSaver.SaveURL(link, audit.Event(r, storage.AuditActionCreate, alias, "", link.URL))
 		if errors.Is(err, storage.ErrURLAlreadyExists) {
-			log.Info("url already exists", slog.String("url", req.URL))
+			log.Info("url already exists", slog.String("url", link.URL))
 			render.JSON(w, r, resp.Error("url already exists"))
 			return
 		}
@@ -83,8 +191,47 @@ func NewURL(urlSaver URLSaver) http.HandlerFunc {
 			return
 		}
 
-		log.Info("url added", slog.String("url", req.URL))
+		log.Info("url added", slog.String("url", link.URL))
+
+		render.JSON(w, r, Response{Response: resp.OK(), Alias: ws.Unalias(alias), ShortURL: shortURLs.URL(r, alias)})
+	}
+}
+
+func rules(reqRules []Rule) []storage.Rule {
+	var res []storage.Rule
+	for _, rule := range reqRules {
+		res = append(res, storage.Rule{
+			Device:   rule.Device,
+			Language: rule.Language,
+			Country:  strings.ToUpper(rule.Country),
+			URL:      rule.URL,
+		})
+	}
+	return res
+}
+
+func destinations(reqDestinations []Destination) []storage.Destination {
+	var res []storage.Destination
+	for _, destination := range reqDestinations {
+		res = append(res, storage.Destination{
+			URL:    destination.URL,
+			Weight: destination.Weight,
+		})
+	}
+	return res
+}
+
+// redacted hides the password from the logs.
+func redacted(req Request) Request {
+	if req.Password != "" {
+		req.Password = "***"
+	}
+	return req
+}
 
-		render.JSON(w, r, Response{Response: resp.OK(), Alias: alias})
+func activeFrom(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
 	}
+	return *t
 }
@@ -7,8 +7,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"url-shortener/internal/config"
 	"url-shortener/internal/http-server/handlers/url/save/mocks"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/linkchain"
+	linkchainMocks "url-shortener/internal/lib/linkchain/mocks"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/lib/validation"
+	"url-shortener/internal/storage"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -19,6 +28,7 @@ func TestSaveHandler(t *testing.T) {
 		name      string
 		alias     string
 		url       string
+		password  string
 		respError string
 		mockError error
 	}{
@@ -44,6 +54,37 @@ func TestSaveHandler(t *testing.T) {
 			url:       "invalid invalid",
 			respError: "field URL is not a valid URL",
 		},
+		{
+			name:      "javascript url",
+			alias:     "test",
+			url:       "javascript:alert(1)",
+			respError: "field URL has a scheme that is not allowed",
+		},
+		{
+			name:      "private network url",
+			alias:     "test",
+			url:       "http://169.254.169.254/latest/meta-data",
+			respError: "field URL points to a private network",
+		},
+		{
+			name:      "alias with slash",
+			alias:     "a/b",
+			url:       "https://ya.ru",
+			respError: "field Alias contains characters that are not allowed",
+		},
+		{
+			name:      "reserved alias",
+			alias:     "URL",
+			url:       "https://ya.ru",
+			respError: "field Alias is reserved",
+		},
+		{
+			name:      "password too long in bytes",
+			alias:     "test",
+			url:       "https://ya.ru",
+			password:  strings.Repeat("пароль", 7),
+			respError: "field Password must be at most 72 bytes long",
+		},
 		{
 			name:      "SaveURL error",
 			alias:     "test",
@@ -60,14 +101,20 @@ func TestSaveHandler(t *testing.T) {
 			urlSaverMock := mocks.NewURLSaver(t)
 
 			if tt.respError == "" || tt.mockError != nil {
-				urlSaverMock.On("SaveURL", tt.url, mock.AnythingOfType("string")).
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(link storage.Link) bool {
+					return link.URL == tt.url && link.Alias != ""
+				}), mock.MatchedBy(func(event storage.AuditEvent) bool {
+					return event.Action == storage.AuditActionCreate && event.NewValue == tt.url
+				})).
 					Return(tt.mockError).
 					Once()
 			}
 
-			handler := NewURL(urlSaverMock)
+			shortURLs := shorturl.New("https://sho.rt", nil, nil)
 
-			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, tt.url, tt.alias)
+			handler := NewURL(urlSaverMock, linkchain.New(linkchainMocks.NewURLGetter(t), linkchain.Own{}, 3, false), newValidator(t), shortURLs)
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "%s", "password": "%s"}`, tt.url, tt.alias, tt.password)
 
 			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
 			require.NoError(t, err)
@@ -83,7 +130,149 @@ func TestSaveHandler(t *testing.T) {
 
 			require.NoError(t, json.Unmarshal([]byte(body), &resp))
 
-			require.Equal(t, tt.respError, tt.respError)
+			require.Equal(t, tt.respError, resp.Error)
+			if tt.respError == "" && tt.alias != "" {
+				require.Equal(t, "https://sho.rt/"+tt.alias, resp.ShortURL)
+			}
+		})
+	}
+}
+
+func TestSaveHandlerWorkspace(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", mock.MatchedBy(func(link storage.Link) bool {
+		return link.Alias == "go.brand.com/sale" && link.Workspace == "brand"
+	}), mock.AnythingOfType("storage.AuditEvent")).
+		Return(nil).
+		Once()
+
+	shortURLs := shorturl.New("https://sho.rt", []string{"go.brand.com"}, nil)
+
+	handler := workspace.Set(workspace.Workspace{Name: "brand", Prefix: "brand", Domain: "go.brand.com"})(
+		NewURL(urlSaverMock, linkchain.New(linkchainMocks.NewURLGetter(t), linkchain.Own{}, 3, false), newValidator(t), shortURLs),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(`{"url": "https://ya.ru", "alias": "sale"}`)))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	require.Empty(t, resp.Error)
+	require.Equal(t, "sale", resp.Alias)
+	require.Equal(t, "https://go.brand.com/sale", resp.ShortURL)
+}
+
+func newValidator(t *testing.T) *validation.Validator {
+	aliasPolicy, err := aliaspolicy.New("^[a-zA-Z0-9_-]+$", 3, 64, []string{"url"})
+	require.NoError(t, err)
+
+	validate, err := validation.New(config.GetConfig().URLPolicy, aliasPolicy)
+	require.NoError(t, err)
+
+	return validate
+}
+
+func TestSaveHandlerQuery(t *testing.T) {
+	testCases := []struct {
+		name    string
+		url     string
+		flatten bool
+		saved   string
+	}{
+		{
+			name:  "destination",
+			url:   "https://93.184.216.35/page",
+			saved: "https://93.184.216.35/page?utm_source=news&lang=en",
+		},
+		{
+			name:  "chain",
+			url:   "https://93.184.216.34/hop",
+			saved: "https://93.184.216.34/hop?utm_source=news&lang=en",
+		},
+		{
+			name:    "flattened chain",
+			url:     "https://93.184.216.34/hop",
+			flatten: true,
+			saved:   "https://ya.ru/page?ref=x&utm_source=news&lang=en",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("SaveURL", mock.MatchedBy(func(link storage.Link) bool {
+				return link.URL == tt.saved
+			}), mock.AnythingOfType("storage.AuditEvent")).
+				Return(nil).
+				Once()
+
+			urlGetterMock := linkchainMocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "hop").Return(storage.Link{Alias: "hop", URL: "https://ya.ru/page?ref=x"}, nil).Maybe()
+			chains := linkchain.New(urlGetterMock, linkchain.Own{Hosts: []string{"93.184.216.34"}}, 3, tt.flatten)
+
+			handler := NewURL(urlSaverMock, chains, newValidator(t), shorturl.New("https://sho.rt", nil, nil))
+
+			input := fmt.Sprintf(`{"url": "%s", "alias": "test", "utm": {"source": "news"}, "query": {"lang": "en"}}`, tt.url)
+			req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(input))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Empty(t, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandlerRules(t *testing.T) {
+	testCases := []struct {
+		name      string
+		rule      string
+		respError string
+	}{
+		{
+			name: "device",
+			rule: `{"device": "ios", "url": "https://93.184.216.35/app"}`,
+		},
+		{
+			name: "country",
+			rule: `{"country": "de", "url": "https://93.184.216.35/de"}`,
+		},
+		{
+			name:      "no condition",
+			rule:      `{"url": "https://93.184.216.35/other"}`,
+			respError: "field Device is required when none of Language, Country is set",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			if tt.respError == "" {
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(link storage.Link) bool {
+					return len(link.Rules) == 1
+				}), mock.AnythingOfType("storage.AuditEvent")).
+					Return(nil).
+					Once()
+			}
+
+			handler := NewURL(urlSaverMock, linkchain.New(linkchainMocks.NewURLGetter(t), linkchain.Own{}, 3, false),
+				newValidator(t), shorturl.New("https://sho.rt", nil, nil))
+
+			input := fmt.Sprintf(`{"url": "https://93.184.216.34/", "rules": [%s]}`, tt.rule)
+			req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(input))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
 		})
 	}
 }
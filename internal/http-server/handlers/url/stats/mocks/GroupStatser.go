@@ -0,0 +1,56 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url-shortener/internal/storage"
+)
+
+// GroupStatser is an autogenerated mock type for the GroupStatser type
+type GroupStatser struct {
+	mock.Mock
+}
+
+// GroupStats provides a mock function with given fields: filter
+func (_m *GroupStatser) GroupStats(filter storage.LinkFilter) (storage.GroupStats, error) {
+	ret := _m.Called(filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GroupStats")
+	}
+
+	var r0 storage.GroupStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(storage.LinkFilter) (storage.GroupStats, error)); ok {
+		return rf(filter)
+	}
+	if rf, ok := ret.Get(0).(func(storage.LinkFilter) storage.GroupStats); ok {
+		r0 = rf(filter)
+	} else {
+		r0 = ret.Get(0).(storage.GroupStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(storage.LinkFilter) error); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewGroupStatser creates a new instance of GroupStatser. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewGroupStatser(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *GroupStatser {
+	mock := &GroupStatser{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
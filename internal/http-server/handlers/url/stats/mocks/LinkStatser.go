@@ -0,0 +1,56 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	storage "url-shortener/internal/storage"
+)
+
+// LinkStatser is an autogenerated mock type for the LinkStatser type
+type LinkStatser struct {
+	mock.Mock
+}
+
+// LinkStats provides a mock function with given fields: alias
+func (_m *LinkStatser) LinkStats(alias string) (storage.LinkStats, error) {
+	ret := _m.Called(alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkStats")
+	}
+
+	var r0 storage.LinkStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (storage.LinkStats, error)); ok {
+		return rf(alias)
+	}
+	if rf, ok := ret.Get(0).(func(string) storage.LinkStats); ok {
+		r0 = rf(alias)
+	} else {
+		r0 = ret.Get(0).(storage.LinkStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewLinkStatser creates a new instance of LinkStatser. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLinkStatser(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LinkStatser {
+	mock := &LinkStatser{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
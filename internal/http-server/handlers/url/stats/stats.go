@@ -0,0 +1,130 @@
+package stats
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Destination struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+	Hits   int64  `json:"hits"`
+}
+
+type Response struct {
+	resp.Response
+	Alias           string        `json:"alias,omitempty"`
+	Deleted         bool          `json:"deleted,omitempty"`
+	MaxClicks       int           `json:"max_clicks,omitempty"`
+	RemainingClicks int           `json:"remaining_clicks,omitempty"`
+	Destinations    []Destination `json:"destinations,omitempty"`
+}
+
+type GroupResponse struct {
+	resp.Response
+	Links          int64 `json:"links"`
+	VariantHits    int64 `json:"variant_hits"`
+	RedeemedClicks int64 `json:"redeemed_clicks"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2 --name=LinkStatser
+type LinkStatser interface {
+	LinkStats(alias string) (storage.LinkStats, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2 --name=GroupStatser
+type GroupStatser interface {
+	GroupStats(filter storage.LinkFilter) (storage.GroupStats, error)
+}
+
+var log *slog.Logger = sl.GetLogger()
+
+// LinkStats returns the counters of a link, see storage.LinkStats.
+func LinkStats(linkStatser LinkStatser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.stats.LinkStats"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("failed to get alias from url", slog.String("url", r.URL.Path))
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		ws := workspace.FromContext(r.Context())
+		alias = ws.Alias(alias)
+
+		stats, err := linkStatser.LinkStats(alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url for alias not found", slog.String("alias", alias))
+			render.JSON(w, r, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get stats", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		res := Response{
+			Response:        resp.OK(),
+			Alias:           ws.Unalias(stats.Alias),
+			Deleted:         stats.Deleted,
+			MaxClicks:       stats.MaxClicks,
+			RemainingClicks: stats.RemainingClicks,
+		}
+		for _, destination := range stats.Destinations {
+			res.Destinations = append(res.Destinations, Destination(destination))
+		}
+
+		render.JSON(w, r, res)
+	}
+}
+
+// GroupStats sums the counters of the links of the namespace filtered by the
+// tag and folder query parameters, see storage.GroupStats.
+func GroupStats(groupStatser GroupStatser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.stats.GroupStats"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+		ws := workspace.FromContext(r.Context())
+		stats, err := groupStatser.GroupStats(storage.LinkFilter{
+			Workspace:    ws.Name,
+			Namespace:    ws.Namespace(),
+			OwnNamespace: true,
+			Tag:          query.Get("tag"),
+			Folder:       query.Get("folder"),
+		})
+		if err != nil {
+			log.Error("failed to get stats", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		render.JSON(w, r, GroupResponse{
+			Response:       resp.OK(),
+			Links:          stats.Links,
+			VariantHits:    stats.VariantHits,
+			RedeemedClicks: stats.RedeemedClicks,
+		})
+	}
+}
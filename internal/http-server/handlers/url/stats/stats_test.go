@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/http-server/handlers/url/stats/mocks"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkStatsHandler(t *testing.T) {
+	testCases := []struct {
+		name      string
+		stats     storage.LinkStats
+		respError string
+		mockError error
+	}{
+		{
+			name: "success",
+			stats: storage.LinkStats{
+				Alias:        "test",
+				Destinations: []storage.DestinationStats{{URL: "https://ya.ru", Weight: 1, Hits: 3}},
+			},
+		},
+		{
+			name:      "not found",
+			respError: "not found",
+			mockError: storage.ErrURLNotFound,
+		},
+		{
+			name:      "LinkStats error",
+			respError: "internal error",
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			linkStatserMock := mocks.NewLinkStatser(t)
+			linkStatserMock.On("LinkStats", "test").
+				Return(tt.stats, tt.mockError).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/url/{alias}/stats", LinkStats(linkStatserMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/url/test/stats", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
+			require.Len(t, resp.Destinations, len(tt.stats.Destinations))
+		})
+	}
+}
+
+func TestGroupStatsHandler(t *testing.T) {
+	testCases := []struct {
+		name      string
+		workspace workspace.Workspace
+		query     string
+		filter    storage.LinkFilter
+		stats     storage.GroupStats
+		respError string
+		mockError error
+	}{
+		{
+			name:   "all links",
+			filter: storage.LinkFilter{OwnNamespace: true},
+			stats:  storage.GroupStats{Links: 3, VariantHits: 5, RedeemedClicks: 1},
+		},
+		{
+			name:      "by tag in a workspace",
+			workspace: workspace.Workspace{Name: "brand", Prefix: "brand"},
+			query:     "?tag=ads&folder=spring",
+			filter: storage.LinkFilter{
+				Workspace:    "brand",
+				Namespace:    "brand",
+				OwnNamespace: true,
+				Tag:          "ads",
+				Folder:       "spring",
+			},
+			stats: storage.GroupStats{Links: 1},
+		},
+		{
+			name:      "GroupStats error",
+			filter:    storage.LinkFilter{OwnNamespace: true},
+			respError: "internal error",
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			groupStatserMock := mocks.NewGroupStatser(t)
+			groupStatserMock.On("GroupStats", tt.filter).
+				Return(tt.stats, tt.mockError).
+				Once()
+
+			handler := workspace.Set(tt.workspace)(GroupStats(groupStatserMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/url/stats"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp GroupResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
+			require.Equal(t, tt.stats.Links, resp.Links)
+			require.Equal(t, tt.stats.VariantHits, resp.VariantHits)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	storage "url-shortener/internal/storage"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// TagEditor is an autogenerated mock type for the TagEditor type
+type TagEditor struct {
+	mock.Mock
+}
+
+// AddTags provides a mock function with given fields: alias, _a1, events
+func (_m *TagEditor) AddTags(alias string, _a1 []string, events ...storage.AuditEvent) error {
+	_va := make([]interface{}, len(events))
+	for _i := range events {
+		_va[_i] = events[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, alias, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddTags")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string, ...storage.AuditEvent) error); ok {
+		r0 = rf(alias, _a1, events...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveTags provides a mock function with given fields: alias, _a1, events
+func (_m *TagEditor) RemoveTags(alias string, _a1 []string, events ...storage.AuditEvent) error {
+	_va := make([]interface{}, len(events))
+	for _i := range events {
+		_va[_i] = events[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, alias, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveTags")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string, ...storage.AuditEvent) error); ok {
+		r0 = rf(alias, _a1, events...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewTagEditor creates a new instance of TagEditor. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTagEditor(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TagEditor {
+	mock := &TagEditor{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
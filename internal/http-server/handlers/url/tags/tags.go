@@ -0,0 +1,133 @@
+package tags
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	Tags []string `json:"tags" validate:"required,min=1,max=32,dive,required,max=64"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2 --name=TagEditor
+type TagEditor interface {
+	AddTags(alias string, tags []string, events ...storage.AuditEvent) error
+	RemoveTags(alias string, tags []string, events ...storage.AuditEvent) error
+}
+
+var log *slog.Logger = sl.GetLogger()
+
+// AddTags attaches the tags of the request body to the alias.
+func AddTags(tagEditor TagEditor) http.HandlerFunc {
+	validate := validator.New()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.tags.AddTags"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("failed to get alias from url", slog.String("url", r.URL.Path))
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		alias = workspace.FromContext(r.Context()).Alias(alias)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("falied to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err = validate.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", sl.Err(err))
+			render.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		err = tagEditor.AddTags(alias, req.Tags, audit.Event(r, storage.AuditActionTag, alias, "", strings.Join(req.Tags, ",")))
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url for alias not found", slog.String("alias", alias))
+			render.JSON(w, r, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Info("failed to add tags", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("tags added", slog.String("alias", alias), slog.Any("tags", req.Tags))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// RemoveTag detaches the tag of the tag query parameter from the alias. The
+// tag is not part of the path, which would cut it at a dot or a slash.
+func RemoveTag(tagEditor TagEditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.tags.RemoveTag"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		tag := r.URL.Query().Get("tag")
+		if alias == "" || tag == "" {
+			log.Info("failed to get alias or tag from url", slog.String("url", r.URL.Path))
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		alias = workspace.FromContext(r.Context()).Alias(alias)
+
+		err := tagEditor.RemoveTags(alias, []string{tag}, audit.Event(r, storage.AuditActionUntag, alias, tag, ""))
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url for alias not found", slog.String("alias", alias))
+			render.JSON(w, r, resp.Error("not found"))
+			return
+		}
+		if errors.Is(err, storage.ErrTagNotFound) {
+			log.Info("alias has no such tag", slog.String("alias", alias), slog.String("tag", tag))
+			render.JSON(w, r, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Info("failed to remove tag", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("tag removed", slog.String("alias", alias), slog.String("tag", tag))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,154 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/handlers/url/tags/mocks"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddTagsHandler(t *testing.T) {
+	testCases := []struct {
+		name      string
+		alias     string
+		body      string
+		tags      []string
+		respError string
+		mockError error
+	}{
+		{
+			name:  "Success",
+			alias: "test",
+			body:  `{"tags": ["ads", "q3"]}`,
+			tags:  []string{"ads", "q3"},
+		},
+		{
+			name:      "No tags",
+			alias:     "test",
+			body:      `{"tags": []}`,
+			respError: "field Tags must be at least 1",
+		},
+		{
+			name:      "Not found",
+			alias:     "test",
+			body:      `{"tags": ["ads"]}`,
+			tags:      []string{"ads"},
+			respError: "not found",
+			mockError: storage.ErrURLNotFound,
+		},
+		{
+			name:      "AddTags error",
+			alias:     "test",
+			body:      `{"tags": ["ads"]}`,
+			tags:      []string{"ads"},
+			respError: "internal error",
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tagEditorMock := mocks.NewTagEditor(t)
+			if tt.tags != nil {
+				tagEditorMock.On("AddTags", tt.alias, tt.tags, mock.MatchedBy(func(event storage.AuditEvent) bool {
+					return event.Action == storage.AuditActionTag && event.Alias == tt.alias
+				})).
+					Return(tt.mockError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/tags", AddTags(tagEditorMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/url/"+tt.alias+"/tags", bytes.NewReader([]byte(tt.body)))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
+		})
+	}
+}
+
+func TestRemoveTagHandler(t *testing.T) {
+	testCases := []struct {
+		name      string
+		url       string
+		tag       string
+		respError string
+		mockError error
+	}{
+		{
+			name: "Success",
+			url:  "/url/test/tags?tag=ads",
+			tag:  "ads",
+		},
+		{
+			name: "Tag with a dot and a slash",
+			url:  "/url/test/tags?tag=" + url.QueryEscape("v1.2/beta"),
+			tag:  "v1.2/beta",
+		},
+		{
+			name:      "No tag",
+			url:       "/url/test/tags",
+			respError: "invalid request",
+		},
+		{
+			name:      "Tag not found",
+			url:       "/url/test/tags?tag=ads",
+			tag:       "ads",
+			respError: "not found",
+			mockError: storage.ErrTagNotFound,
+		},
+		{
+			name:      "Alias not found",
+			url:       "/url/test/tags?tag=ads",
+			tag:       "ads",
+			respError: "not found",
+			mockError: storage.ErrURLNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tagEditorMock := mocks.NewTagEditor(t)
+			if tt.tag != "" {
+				tagEditorMock.On("RemoveTags", "test", []string{tt.tag}, mock.MatchedBy(func(event storage.AuditEvent) bool {
+					return event.Action == storage.AuditActionUntag && event.OldValue == tt.tag
+				})).
+					Return(tt.mockError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Use(middleware.URLFormat)
+			r.Delete("/url/{alias}/tags", RemoveTag(tagEditorMock))
+
+			req := httptest.NewRequest(http.MethodDelete, tt.url, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
+		})
+	}
+}
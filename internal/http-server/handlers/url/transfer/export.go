@@ -0,0 +1,77 @@
+package transfer
+
+import (
+	"log/slog"
+	"net/http"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/linkfile"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+//go:generate go run github.com/vektra/mockery/v2 --name=URLExporter
+type URLExporter interface {
+	ExportURLs(filter storage.LinkFilter, fn func(link storage.Link) error) error
+}
+
+// Export streams the links of the namespace of the workspace as CSV or JSON
+// lines, in the format Import reads. The response may take
+// HTTPServer.TransferTimeout.
+func Export(urlExporter URLExporter) http.HandlerFunc {
+	cfg := config.GetConfig()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.transfer.Export"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		extendDeadlines(w, log, cfg.HTTPServer.TransferTimeout)
+
+		format, err := formatOf(r)
+		if err != nil {
+			log.Info("invalid format", sl.Err(err))
+			render.JSON(w, r, resp.Error("invalid format"))
+			return
+		}
+
+		contentType := "application/x-ndjson"
+		if format == linkfile.FormatCSV {
+			contentType = "text/csv"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="links.`+format+`"`)
+
+		rows, err := linkfile.NewWriter(format, w)
+		if err != nil {
+			log.Error("failed to write export", sl.Err(err))
+			return
+		}
+
+		ws := workspace.FromContext(r.Context())
+		filter := storage.LinkFilter{Workspace: ws.Name, Namespace: ws.Namespace(), OwnNamespace: true}
+
+		exported := 0
+		err = urlExporter.ExportURLs(filter, func(link storage.Link) error {
+			exported++
+			return rows.Write(linkfile.NewRow(ws, link))
+		})
+		if err == nil {
+			err = rows.Flush()
+		}
+		if err != nil {
+			// The response is already streaming, the client gets a truncated export.
+			log.Error("failed to export urls", slog.Int("exported", exported), sl.Err(err))
+			return
+		}
+
+		log.Info("links exported", slog.Int("exported", exported))
+	}
+}
@@ -0,0 +1,178 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/middleware/sso"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/linkchain"
+	"url-shortener/internal/lib/linkfile"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/lib/validation"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type ImportResponse struct {
+	resp.Response
+	DryRun bool `json:"dry_run"`
+	linkfile.Report
+}
+
+//go:generate go run github.com/vektra/mockery/v2 --name=URLImporter
+type URLImporter interface {
+	BeginImport() (storage.Import, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2 --name=CacheInvalidator
+type CacheInvalidator interface {
+	Invalidate(alias string) error
+}
+
+var log *slog.Logger = sl.GetLogger()
+
+// Import saves the links of a CSV or JSON lines body in a single
+// transaction, opened once every row is read and checked. An alias that is taken is skipped, overwritten or fails the
+// whole import, as set by the on_conflict query parameter. With dry_run
+// nothing is saved, the report is the same. The request may take
+// HTTPServer.TransferTimeout.
+func Import(
+	urlImporter URLImporter,
+	cacheInvalidator CacheInvalidator,
+	chains *linkchain.Chains,
+	validate *validation.Validator,
+	shortURLs *shorturl.Builder,
+) http.HandlerFunc {
+	cfg := config.GetConfig()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.transfer.Import"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		extendDeadlines(w, log, cfg.HTTPServer.TransferTimeout)
+
+		query := r.URL.Query()
+		dryRun := query.Get("dry_run") == "true" || query.Get("dry_run") == "1"
+		onConflict := query.Get("on_conflict")
+		if onConflict == "" {
+			onConflict = linkfile.ConflictFail
+		}
+		if onConflict != linkfile.ConflictSkip && onConflict != linkfile.ConflictOverwrite && onConflict != linkfile.ConflictFail {
+			log.Info("invalid conflict policy", slog.String("on_conflict", onConflict))
+			render.JSON(w, r, resp.Error("invalid on_conflict"))
+			return
+		}
+
+		format, err := formatOf(r)
+		if err != nil {
+			log.Info("invalid format", sl.Err(err))
+			render.JSON(w, r, resp.Error("invalid format"))
+			return
+		}
+
+		rows, err := linkfile.NewReader(format, r.Body)
+		if err != nil {
+			log.Info("failed to read import", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to read import"))
+			return
+		}
+
+		importer := linkfile.Importer{
+			Validate:    validate.Validate,
+			Policy:      validate.URLPolicy,
+			Chains:      chains,
+			AliasLength: cfg.AliasLength,
+			Workspace:   workspace.FromContext(r.Context()),
+			Owner:       sso.Email(r.Context()),
+			OnConflict:  onConflict,
+			Event:       audit.Event(r, storage.AuditActionImport, "", "", ""),
+		}
+		batch, err := importer.Prepare(rows)
+		if errors.Is(err, linkfile.ErrRead) {
+			log.Info("failed to read import", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to read import"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check import", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		imp, err := urlImporter.BeginImport()
+		if err != nil {
+			log.Error("failed to begin import", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+		defer func() {
+			if err := imp.Rollback(); err != nil {
+				log.Error("failed to roll back import", sl.Err(err))
+			}
+		}()
+
+		report, err := importer.Save(imp, batch)
+		for i := range report.Rows {
+			if report.Rows[i].Result == linkfile.ResultImported || report.Rows[i].Result == linkfile.ResultOverwritten {
+				report.Rows[i].ShortURL = shortURLs.URL(r, importer.Workspace.Alias(report.Rows[i].Alias))
+			}
+		}
+		res := ImportResponse{DryRun: dryRun, Report: report}
+
+		switch {
+		case errors.Is(err, linkfile.ErrConflict):
+			log.Info("alias already exists", sl.Err(err))
+			res.Response = resp.Error(fmt.Sprintf("alias already exists in row %d", len(report.Rows)))
+			render.JSON(w, r, res)
+			return
+		case err != nil:
+			log.Error("failed to import urls", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		if dryRun {
+			log.Info("import checked", slog.Int("rows", len(res.Rows)))
+			res.Response = resp.OK()
+			render.JSON(w, r, res)
+			return
+		}
+
+		if err = imp.Commit(); err != nil {
+			log.Error("failed to commit import", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		// The import is committed, every alias is invalidated even if one fails.
+		invalidated := true
+		for _, link := range report.Saved {
+			if err = cacheInvalidator.Invalidate(link.Alias); err != nil {
+				log.Error("failed to invalidate cache", slog.String("alias", link.Alias), sl.Err(err))
+				invalidated = false
+			}
+		}
+		if !invalidated {
+			res.Response = resp.Error("links imported, failed to invalidate cache")
+			render.JSON(w, r, res)
+			return
+		}
+
+		log.Info("links imported", slog.Int("imported", res.Imported), slog.Int("overwritten", res.Overwritten))
+
+		res.Response = resp.OK()
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,46 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	storage "url-shortener/internal/storage"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// URLExporter is an autogenerated mock type for the URLExporter type
+type URLExporter struct {
+	mock.Mock
+}
+
+// ExportURLs provides a mock function with given fields: filter, fn
+func (_m *URLExporter) ExportURLs(filter storage.LinkFilter, fn func(storage.Link) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportURLs")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(storage.LinkFilter, func(storage.Link) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewURLExporter creates a new instance of URLExporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLExporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLExporter {
+	mock := &URLExporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,58 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	storage "url-shortener/internal/storage"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// URLImporter is an autogenerated mock type for the URLImporter type
+type URLImporter struct {
+	mock.Mock
+}
+
+// BeginImport provides a mock function with no fields
+func (_m *URLImporter) BeginImport() (storage.Import, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginImport")
+	}
+
+	var r0 storage.Import
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (storage.Import, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() storage.Import); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(storage.Import)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewURLImporter creates a new instance of URLImporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLImporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLImporter {
+	mock := &URLImporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,42 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"url-shortener/internal/lib/linkfile"
+	sl "url-shortener/pkg/logger/slog"
+)
+
+// formatOf returns the format from the format query parameter, falling back
+// to the Content-Type of an import.
+func formatOf(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case linkfile.FormatCSV, linkfile.FormatJSONL:
+		return format, nil
+	case "":
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+			return linkfile.FormatCSV, nil
+		}
+		return linkfile.FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// extendDeadlines lets an import or export run for timeout instead of the
+// read and write timeouts of the server, which would cut it off.
+func extendDeadlines(w http.ResponseWriter, log *slog.Logger, timeout time.Duration) {
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(timeout)
+
+	if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Warn("failed to extend read deadline", sl.Err(err))
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Warn("failed to extend write deadline", sl.Err(err))
+	}
+}
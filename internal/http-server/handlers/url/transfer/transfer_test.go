@@ -0,0 +1,270 @@
+package transfer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/handlers/url/transfer/mocks"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/linkchain"
+	linkchainMocks "url-shortener/internal/lib/linkchain/mocks"
+	"url-shortener/internal/lib/linkfile"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/lib/validation"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeImport keeps the links of an import in memory.
+type fakeImport struct {
+	links     map[string]storage.Link
+	saved     map[string]storage.Link
+	committed bool
+}
+
+func newFakeImport(links ...storage.Link) *fakeImport {
+	f := &fakeImport{links: make(map[string]storage.Link), saved: make(map[string]storage.Link)}
+	for _, link := range links {
+		f.links[link.Alias] = link
+	}
+	return f
+}
+
+func (f *fakeImport) LinkWorkspace(alias string) (string, error) {
+	if link, ok := f.saved[alias]; ok {
+		return link.Workspace, nil
+	}
+	if link, ok := f.links[alias]; ok {
+		return link.Workspace, nil
+	}
+	return "", storage.ErrURLNotFound
+}
+
+func (f *fakeImport) SaveURL(link storage.Link, overwrite bool, events ...storage.AuditEvent) error {
+	if _, err := f.LinkWorkspace(link.Alias); err == nil && !overwrite {
+		return storage.ErrURLAlreadyExists
+	}
+	f.saved[link.Alias] = link
+	return nil
+}
+
+func (f *fakeImport) Commit() error {
+	f.committed = true
+	return nil
+}
+
+func (f *fakeImport) Rollback() error {
+	return nil
+}
+
+func TestImportHandler(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		contentType string
+		body        string
+		respError   string
+		committed   bool
+		saved       []string
+		results     []string
+		invalidate  error
+	}{
+		{
+			name:        "csv",
+			contentType: "text/csv",
+			body:        "alias,url,tags\nnew,https://ya.ru,\"ads,q3\"\nbad,not a url,\n",
+			committed:   true,
+			saved:       []string{"new"},
+			results:     []string{linkfile.ResultImported, linkfile.ResultFailed},
+		},
+		{
+			name:      "jsonl skip",
+			query:     "?on_conflict=skip",
+			body:      `{"alias": "taken", "url": "https://ya.ru"}` + "\n" + `{"alias": "new", "url": "https://go.dev"}`,
+			committed: true,
+			saved:     []string{"new"},
+			results:   []string{linkfile.ResultSkipped, linkfile.ResultImported},
+		},
+		{
+			name:      "overwrite",
+			query:     "?on_conflict=overwrite",
+			body:      `{"alias": "taken", "url": "https://ya.ru"}` + "\n" + `{"alias": "other", "url": "https://ya.ru"}`,
+			committed: true,
+			saved:     []string{"taken"},
+			results:   []string{linkfile.ResultOverwritten, linkfile.ResultFailed},
+		},
+		{
+			name:      "fail",
+			body:      `{"alias": "new", "url": "https://ya.ru"}` + "\n" + `{"alias": "taken", "url": "https://ya.ru"}`,
+			respError: "alias already exists in row 2",
+			saved:     []string{"new"},
+			results:   []string{linkfile.ResultImported, linkfile.ResultFailed},
+		},
+		{
+			name: "redirect chains",
+			body: `{"alias": "first", "url": "https://sho.rt/second"}` + "\n" + `{"alias": "second", "url": "https://ya.ru"}` + "\n" +
+				`{"alias": "hop", "url": "https://sho.rt/loop"}` + "\n" + `{"alias": "loop", "url": "https://sho.rt/hop"}` + "\n" +
+				`{"alias": "lost", "url": "https://sho.rt/missing"}`,
+			committed: true,
+			saved:     []string{"first", "second"},
+			results: []string{
+				linkfile.ResultImported, linkfile.ResultImported,
+				linkfile.ResultFailed, linkfile.ResultFailed, linkfile.ResultFailed,
+			},
+		},
+		{
+			name:    "dry run",
+			query:   "?dry_run=true",
+			body:    `{"alias": "new", "url": "https://ya.ru"}` + "\n" + `{"alias": "old", "url": "https://ya.ru", "expires_at": "2030-01-01"}` + "\n{",
+			saved:   []string{"new", "old"},
+			results: []string{linkfile.ResultImported, linkfile.ResultImported, linkfile.ResultFailed},
+		},
+		{
+			name: "expiry",
+			body: `{"alias": "day", "url": "https://ya.ru", "expires_at": "2030-01-01"}` + "\n" +
+				`{"alias": "time", "url": "https://ya.ru", "expires_at": "2030-01-01T12:00:00+03:00"}` + "\n" +
+				`{"alias": "bad", "url": "https://ya.ru", "expires_at": "next week"}`,
+			committed: true,
+			saved:     []string{"day", "time"},
+			results:   []string{linkfile.ResultImported, linkfile.ResultImported, linkfile.ResultFailed},
+		},
+		{
+			name:      "private network",
+			body:      `{"alias": "local", "url": "http://127.1/"}` + "\n" + `{"alias": "new", "url": "https://ya.ru"}`,
+			committed: true,
+			saved:     []string{"new"},
+			results:   []string{linkfile.ResultFailed, linkfile.ResultImported},
+		},
+		{
+			name:       "invalidation failed",
+			body:       `{"alias": "new", "url": "https://ya.ru"}`,
+			invalidate: errors.New("connection refused"),
+			respError:  "links imported, failed to invalidate cache",
+			committed:  true,
+			saved:      []string{"new"},
+			results:    []string{linkfile.ResultImported},
+		},
+		{
+			name:      "invalid conflict policy",
+			query:     "?on_conflict=merge",
+			respError: "invalid on_conflict",
+		},
+		{
+			name:        "unknown csv column",
+			contentType: "text/csv",
+			body:        "alias,url,clicks\n",
+			respError:   "failed to read import",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			imp := newFakeImport(
+				storage.Link{Alias: "taken", URL: "https://example.com"},
+				storage.Link{Alias: "other", URL: "https://example.com", Workspace: "other"},
+			)
+
+			urlImporterMock := mocks.NewURLImporter(t)
+			urlImporterMock.On("BeginImport").Return(imp, nil).Maybe()
+
+			cacheInvalidatorMock := mocks.NewCacheInvalidator(t)
+			cacheInvalidatorMock.On("Invalidate", mock.Anything).Return(tt.invalidate).Maybe()
+
+			urlGetterMock := linkchainMocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", mock.Anything).Return(storage.Link{}, storage.ErrURLNotFound).Maybe()
+
+			aliasPolicy, err := aliaspolicy.New("^[a-zA-Z0-9_-]+$", 3, 64, []string{"url"})
+			require.NoError(t, err)
+			validate, err := validation.New(config.GetConfig().URLPolicy, aliasPolicy)
+			require.NoError(t, err)
+
+			handler := Import(
+				urlImporterMock,
+				cacheInvalidatorMock,
+				linkchain.New(urlGetterMock, linkchain.Own{Hosts: []string{"sho.rt"}}, 3, false),
+				validate,
+				shorturl.New("https://sho.rt", nil, nil),
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/url/import"+tt.query, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp ImportResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
+			require.Equal(t, tt.committed, imp.committed)
+
+			var results []string
+			for _, row := range resp.Rows {
+				results = append(results, row.Result)
+			}
+			require.Equal(t, tt.results, results)
+
+			var saved []string
+			for alias := range imp.saved {
+				saved = append(saved, alias)
+			}
+			require.ElementsMatch(t, tt.saved, saved)
+		})
+	}
+}
+
+func TestExportHandler(t *testing.T) {
+	testCases := []struct {
+		name   string
+		query  string
+		output string
+	}{
+		{
+			name:   "csv",
+			query:  "?format=csv",
+			output: "alias,url,tags,folder,expires_at\ntest,https://ya.ru,\"ads,q3\",promo,2030-01-01T00:00:00Z\n",
+		},
+		{
+			name:   "jsonl",
+			output: `{"alias":"test","url":"https://ya.ru","tags":["ads","q3"],"folder":"promo","expires_at":"2030-01-01T00:00:00Z"}` + "\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlExporterMock := mocks.NewURLExporter(t)
+			urlExporterMock.On("ExportURLs", storage.LinkFilter{OwnNamespace: true}, mock.Anything).
+				Run(func(args mock.Arguments) {
+					fn := args.Get(1).(func(storage.Link) error)
+					require.NoError(t, fn(storage.Link{
+						Alias:     "test",
+						URL:       "https://ya.ru",
+						Tags:      []string{"ads", "q3"},
+						Folder:    "promo",
+						ExpiresAt: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+					}))
+				}).
+				Return(nil).
+				Once()
+
+			req := httptest.NewRequest(http.MethodGet, "/url/export"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			Export(urlExporterMock).ServeHTTP(rr, req)
+
+			require.Equal(t, tt.output, rr.Body.String())
+		})
+	}
+}
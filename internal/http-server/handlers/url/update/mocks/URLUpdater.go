@@ -0,0 +1,53 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	storage "url-shortener/internal/storage"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// URLUpdater is an autogenerated mock type for the URLUpdater type
+type URLUpdater struct {
+	mock.Mock
+}
+
+// UpdateURL provides a mock function with given fields: alias, _a1, events
+func (_m *URLUpdater) UpdateURL(alias string, _a1 storage.LinkUpdate, events ...storage.AuditEvent) error {
+	_va := make([]interface{}, len(events))
+	for _i := range events {
+		_va[_i] = events[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, alias, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateURL")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, storage.LinkUpdate, ...storage.AuditEvent) error); ok {
+		r0 = rf(alias, _a1, events...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewURLUpdater creates a new instance of URLUpdater. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLUpdater(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLUpdater {
+	mock := &URLUpdater{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,163 @@
+package update
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/linkchain"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/lib/validation"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+// Request changes the settings of a link, the fields left out are kept. An
+// empty folder takes the link out of its folder, an expires_at of
+// 0001-01-01T00:00:00Z makes it never expire.
+type Request struct {
+	URL          *string    `json:"url,omitempty" validate:"omitnil,url,allowed_scheme,allowed_domain,public_network"`
+	RedirectType *int       `json:"redirect_type,omitempty" validate:"omitnil,oneof=301 302 303 307 308"`
+	Passthrough  *bool      `json:"passthrough,omitempty"`
+	Folder       *string    `json:"folder,omitempty" validate:"omitnil,max=128"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Alias    string `json:"alias,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2 --name=URLUpdater
+type URLUpdater interface {
+	UpdateURL(alias string, update storage.LinkUpdate, events ...storage.AuditEvent) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2 --name=CacheInvalidator
+type CacheInvalidator interface {
+	Invalidate(alias string) error
+}
+
+var log *slog.Logger = sl.GetLogger()
+
+// UpdateURL changes the settings of the alias to the ones of the request
+// body. A new url is checked like the url of a new link.
+func UpdateURL(
+	urlUpdater URLUpdater,
+	cacheInvalidator CacheInvalidator,
+	chains *linkchain.Chains,
+	validate *validation.Validator,
+	shortURLs *shorturl.Builder,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const caller = "handlers.url.update.UpdateURL"
+
+		log = log.With(
+			slog.String("caller", caller),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("failed to get alias from url", slog.String("url", r.URL.Path))
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		ws := workspace.FromContext(r.Context())
+		alias = ws.Alias(alias)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("falied to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if req.URL == nil && req.RedirectType == nil && req.Passthrough == nil && req.Folder == nil && req.ExpiresAt == nil {
+			log.Info("nothing to update", slog.String("alias", alias))
+			render.JSON(w, r, resp.Error("nothing to update"))
+			return
+		}
+
+		if err = validate.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", sl.Err(err))
+			render.JSON(w, r, resp.ValidationError(validateErr))
+			return
+		}
+
+		update := storage.LinkUpdate{
+			URL:          req.URL,
+			RedirectType: req.RedirectType,
+			Passthrough:  req.Passthrough,
+			Folder:       req.Folder,
+			ExpiresAt:    req.ExpiresAt,
+		}
+
+		if req.URL != nil {
+			link := storage.Link{Alias: alias, URL: *req.URL}
+			err = chains.Resolve(&link)
+			if errors.Is(err, linkchain.ErrRedirectLoop) {
+				log.Info("url creates a redirect loop", slog.String("url", *req.URL), sl.Err(err))
+				render.JSON(w, r, resp.Error("url creates a redirect loop"))
+				return
+			}
+			if errors.Is(err, linkchain.ErrChainTooLong) {
+				log.Info("url redirect chain is too long", slog.String("url", *req.URL), sl.Err(err))
+				render.JSON(w, r, resp.Error("url redirect chain is too long"))
+				return
+			}
+			if errors.Is(err, linkchain.ErrUnknownTarget) {
+				log.Info("url points to an unknown alias", slog.String("url", *req.URL), sl.Err(err))
+				render.JSON(w, r, resp.Error("url points to an unknown alias"))
+				return
+			}
+			if err != nil {
+				log.Info("failed to resolve redirect chain", sl.Err(err))
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+			update.URL = &link.URL
+		}
+
+		// The old and the new url of the link are filled in by the storage.
+		err = urlUpdater.UpdateURL(alias, update, audit.Event(r, storage.AuditActionUpdate, alias, "", ""))
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url for alias not found", slog.String("alias", alias))
+			render.JSON(w, r, resp.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Info("failed to update url", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		if err = cacheInvalidator.Invalidate(alias); err != nil {
+			log.Error("failed to invalidate cache", slog.String("alias", alias), sl.Err(err))
+			render.JSON(w, r, resp.Error("link updated, failed to invalidate cache"))
+			return
+		}
+
+		log.Info("url for alias updated", slog.String("alias", alias))
+
+		render.JSON(w, r, Response{Response: resp.OK(), Alias: ws.Unalias(alias), ShortURL: shortURLs.URL(r, alias)})
+	}
+}
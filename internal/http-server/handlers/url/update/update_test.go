@@ -0,0 +1,156 @@
+package update
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/handlers/url/update/mocks"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/linkchain"
+	linkchainMocks "url-shortener/internal/lib/linkchain/mocks"
+	"url-shortener/internal/lib/shorturl"
+	"url-shortener/internal/lib/validation"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateHandler(t *testing.T) {
+	testCases := []struct {
+		name          string
+		alias         string
+		workspace     workspace.Workspace
+		key           string
+		body          string
+		update        func(u storage.LinkUpdate) bool
+		respError     string
+		shortURL      string
+		mockError     error
+		invalidateErr error
+	}{
+		{
+			name:  "url",
+			alias: "test",
+			key:   "test",
+			body:  `{"url": "https://93.184.216.34/page"}`,
+			update: func(u storage.LinkUpdate) bool {
+				return u.URL != nil && *u.URL == "https://93.184.216.34/page" && u.Folder == nil
+			},
+			shortURL: "https://sho.rt/test",
+		},
+		{
+			name:      "workspace",
+			alias:     "sale",
+			workspace: workspace.Workspace{Name: "brand", Prefix: "brand"},
+			key:       "brand/sale",
+			body:      `{"folder": "", "expires_at": "2030-01-01T00:00:00Z"}`,
+			update: func(u storage.LinkUpdate) bool {
+				return u.URL == nil && u.Folder != nil && *u.Folder == "" && u.ExpiresAt != nil && u.ExpiresAt.Year() == 2030
+			},
+			shortURL: "https://sho.rt/brand/sale",
+		},
+		{
+			name:      "nothing to update",
+			alias:     "test",
+			body:      `{}`,
+			respError: "nothing to update",
+		},
+		{
+			name:      "empty url",
+			alias:     "test",
+			body:      `{"url": ""}`,
+			respError: "field URL is not a valid URL",
+		},
+		{
+			name:      "invalid redirect type",
+			alias:     "test",
+			body:      `{"redirect_type": 200}`,
+			respError: "field RedirectType must be one of 301 302 303 307 308",
+		},
+		{
+			name:      "redirect loop",
+			alias:     "test",
+			body:      `{"url": "https://sho.rt/test"}`,
+			respError: "url creates a redirect loop",
+		},
+		{
+			name:      "not found",
+			alias:     "test",
+			key:       "test",
+			body:      `{"passthrough": true}`,
+			respError: "not found",
+			mockError: storage.ErrURLNotFound,
+		},
+		{
+			name:      "UpdateURL error",
+			alias:     "test",
+			key:       "test",
+			body:      `{"passthrough": true}`,
+			respError: "internal error",
+			mockError: errors.New("unexpected error"),
+		},
+		{
+			name:          "Invalidate error",
+			alias:         "test",
+			key:           "test",
+			body:          `{"passthrough": true}`,
+			respError:     "link updated, failed to invalidate cache",
+			invalidateErr: errors.New("connection refused"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlUpdaterMock := mocks.NewURLUpdater(t)
+			cacheInvalidatorMock := mocks.NewCacheInvalidator(t)
+			if tt.key != "" {
+				update := tt.update
+				if update == nil {
+					update = func(storage.LinkUpdate) bool { return true }
+				}
+				urlUpdaterMock.On("UpdateURL", tt.key, mock.MatchedBy(update), mock.MatchedBy(func(event storage.AuditEvent) bool {
+					return event.Action == storage.AuditActionUpdate && event.Alias == tt.key &&
+						event.Workspace == tt.workspace.Name
+				})).
+					Return(tt.mockError).
+					Once()
+				if tt.mockError == nil {
+					cacheInvalidatorMock.On("Invalidate", tt.key).Return(tt.invalidateErr).Once()
+				}
+			}
+
+			urlGetterMock := linkchainMocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test").Return(storage.Link{Alias: "test", URL: "https://93.184.216.34/"}, nil).Maybe()
+			chains := linkchain.New(urlGetterMock, linkchain.Own{Hosts: []string{"sho.rt"}}, 3, false)
+
+			aliasPolicy, err := aliaspolicy.New("^[a-zA-Z0-9_-]+$", 3, 64, []string{"url"})
+			require.NoError(t, err)
+			validate, err := validation.New(config.GetConfig().URLPolicy, aliasPolicy)
+			require.NoError(t, err)
+
+			r := chi.NewRouter()
+			r.Use(workspace.Set(tt.workspace))
+			r.Patch("/url/{alias}", UpdateURL(urlUpdaterMock, cacheInvalidatorMock, chains, validate, shorturl.New("https://sho.rt", nil, nil)))
+
+			req := httptest.NewRequest(http.MethodPatch, "/url/"+tt.alias, strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tt.respError, resp.Error)
+			require.Equal(t, tt.shortURL, resp.ShortURL)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package clientip finds the address of the client of a request which came
+// through trusted proxies, e.g. a load balancer.
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Header carries the addresses a request went through, appended by each
+// proxy.
+const Header = "X-Forwarded-For"
+
+type ctxKey struct{}
+
+// IP returns the address of the client of r found by Resolve, the peer
+// address of r if Resolve did not run. It is nil if the address cannot be
+// parsed.
+func IP(r *http.Request) net.IP {
+	if addr, ok := r.Context().Value(ctxKey{}).(netip.Addr); ok {
+		return net.IP(addr.AsSlice())
+	}
+	if addr, ok := peer(r); ok {
+		return net.IP(addr.AsSlice())
+	}
+	return nil
+}
+
+// Resolve takes the client address of requests sent by trustedProxies,
+// addresses or CIDR ranges, from Header. The header is read from the right,
+// the first address which is not a trusted proxy is the client, the ones
+// left of it may be forged. Requests of other peers keep their peer address.
+func Resolve(trustedProxies []string) (func(next http.Handler) http.Handler, error) {
+	const caller = "middleware.clientip.Resolve"
+
+	var trusted []netip.Prefix
+	for _, proxy := range trustedProxies {
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", caller, err)
+		}
+		trusted = append(trusted, prefix)
+	}
+
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client, ok := peer(r)
+			if !ok || !isTrusted(client) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			hops := strings.Split(strings.Join(r.Header.Values(Header), ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+				if err != nil {
+					break
+				}
+				client = addr.Unmap()
+				if !isTrusted(client) {
+					break
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, client)))
+		})
+	}, nil
+}
+
+func peer(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// parsePrefix reads a CIDR range or a single address.
+func parsePrefix(proxy string) (netip.Prefix, error) {
+	proxy = strings.TrimSpace(proxy)
+	if strings.Contains(proxy, "/") {
+		prefix, err := netip.ParsePrefix(proxy)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,103 @@
+package clientip
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		ip         string
+	}{
+		{
+			name:       "direct",
+			remoteAddr: "1.2.3.4:5555",
+			ip:         "1.2.3.4",
+		},
+		{
+			name:       "untrusted peer",
+			remoteAddr: "1.2.3.4:5555",
+			forwarded:  []string{"5.6.7.8"},
+			ip:         "1.2.3.4",
+		},
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.0.0.1:5555",
+			forwarded:  []string{"5.6.7.8"},
+			ip:         "5.6.7.8",
+		},
+		{
+			name:       "forged by the client",
+			remoteAddr: "10.0.0.1:5555",
+			forwarded:  []string{"9.9.9.9, 5.6.7.8"},
+			ip:         "5.6.7.8",
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "10.0.0.1:5555",
+			forwarded:  []string{"5.6.7.8, 192.168.1.1", "10.0.0.2"},
+			ip:         "5.6.7.8",
+		},
+		{
+			name:       "only trusted proxies",
+			remoteAddr: "10.0.0.1:5555",
+			forwarded:  []string{"10.0.0.2"},
+			ip:         "10.0.0.2",
+		},
+		{
+			name:       "invalid hop",
+			remoteAddr: "10.0.0.1:5555",
+			forwarded:  []string{"5.6.7.8, unknown"},
+			ip:         "10.0.0.1",
+		},
+		{
+			name:       "no header",
+			remoteAddr: "10.0.0.1:5555",
+			ip:         "10.0.0.1",
+		},
+		{
+			name:       "ipv6",
+			remoteAddr: "[fd00::1]:5555",
+			forwarded:  []string{"2001:db8::1"},
+			ip:         "2001:db8::1",
+		},
+	}
+
+	resolve, err := Resolve([]string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"})
+	require.NoError(t, err)
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var ip net.IP
+			handler := resolve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip = IP(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, forwarded := range tt.forwarded {
+				req.Header.Add(Header, forwarded)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Equal(t, tt.ip, ip.String())
+		})
+	}
+}
+
+func TestResolveInvalidProxy(t *testing.T) {
+	_, err := Resolve([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+
+	_, err = Resolve([]string{"proxy.local"})
+	require.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	resp "url-shortener/internal/api/response"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+var log *slog.Logger = sl.GetLogger()
+
+// StreamedBody marks the operations of doc whose body is left to the
+// handler, which reads it as it comes. Validating it would buffer it.
+const StreamedBody = "x-streamed-body"
+
+// Validate rejects requests which do not match the operation of doc they
+// are for, answering like the handlers do with an error response. Requests
+// doc has no operation for are passed on, for the router to answer.
+// Authentication is left to sso.IsRequestAdmin and the defaults of doc are
+// not applied, the handlers have their own.
+func Validate(doc *openapi3.T) (func(next http.Handler) http.Handler, error) {
+	const caller = "middleware.openapi.Validate"
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	options := &openapi3filter.Options{
+		SkipSettingDefaults: true,
+	}
+	// Imports are checked row by row by the handler, which reports every
+	// row that fails instead of rejecting the whole file.
+	streamedOptions := *options
+	streamedOptions.ExcludeRequestBody = true
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Checking the security requirements would read the whole body
+			// into memory, for an AuthenticationFunc which might need it.
+			operation := *route.Operation
+			operation.Security = &openapi3.SecurityRequirements{}
+			unsecured := *route
+			unsecured.Operation = &operation
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      &unsecured,
+				Options:    options,
+			}
+			if streamed, _ := route.Operation.Extensions[StreamedBody].(bool); streamed {
+				input.Options = &streamedOptions
+			}
+
+			err = openapi3filter.ValidateRequest(r.Context(), input)
+			if err != nil {
+				log.Info(
+					"request does not match the api",
+					slog.String("caller", caller),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("operation", route.Operation.OperationID),
+					sl.Err(err),
+				)
+				render.JSON(w, r, resp.Error(message(err)))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// message returns the error of the response to a request rejected with err,
+// without the schema dumps of the validation errors.
+func message(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return "invalid request"
+	}
+	if requestErr.Parameter != nil {
+		return "invalid " + requestErr.Parameter.Name
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return "invalid request"
+	}
+	field := strings.Join(schemaErr.JSONPointer(), ".")
+	switch {
+	case field == "":
+		return schemaErr.Reason
+	case schemaErr.SchemaField == "required":
+		return fmt.Sprintf("field %s is a required field", field)
+	default:
+		return fmt.Sprintf("field %s: %s", field, schemaErr.Reason)
+	}
+}
@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"url-shortener/internal/api/openapi"
+	"url-shortener/internal/api/response"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// streamedBody tells the body of a request from a copy of it.
+type streamedBody struct {
+	io.Reader
+}
+
+func (*streamedBody) Close() error {
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		respError   string
+	}{
+		{
+			name:        "valid create",
+			method:      http.MethodPost,
+			path:        "/url",
+			contentType: "application/json",
+			body:        `{"url": "https://ya.ru", "tags": ["ads"], "max_clicks": 3}`,
+			status:      http.StatusOK,
+		},
+		{
+			name:        "create without url",
+			method:      http.MethodPost,
+			path:        "/url",
+			contentType: "application/json",
+			body:        `{"alias": "test"}`,
+			status:      http.StatusOK,
+			respError:   "field url is a required field",
+		},
+		{
+			name:        "create with a string as max clicks",
+			method:      http.MethodPost,
+			path:        "/url",
+			contentType: "application/json",
+			body:        `{"url": "https://ya.ru", "max_clicks": "3"}`,
+			status:      http.StatusOK,
+			respError:   "field max_clicks: value must be an integer",
+		},
+		{
+			name:      "limit out of range",
+			method:    http.MethodGet,
+			path:      "/url?limit=5000",
+			status:    http.StatusOK,
+			respError: "invalid limit",
+		},
+		{
+			name:   "audit is not an alias",
+			method: http.MethodGet,
+			path:   "/url/audit?action=create",
+			status: http.StatusOK,
+		},
+		{
+			name:        "csv import with a short row",
+			method:      http.MethodPost,
+			path:        "/url/import?on_conflict=skip",
+			contentType: "text/csv",
+			body:        "alias,url,tags\ntest,https://ya.ru\n",
+			status:      http.StatusOK,
+		},
+		{
+			name:   "jsonl import without content type",
+			method: http.MethodPost,
+			path:   "/url/import",
+			body:   `{"url": "https://ya.ru"}`,
+			status: http.StatusOK,
+		},
+		{
+			name:      "invalid qr level",
+			method:    http.MethodGet,
+			path:      "/url/test/qr?level=X",
+			status:    http.StatusOK,
+			respError: "invalid level",
+		},
+		{
+			name:   "unknown route",
+			method: http.MethodGet,
+			path:   "/url/test/unknown",
+			status: http.StatusNotFound,
+		},
+	}
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	validate, err := Validate(doc)
+	require.NoError(t, err)
+
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	}
+
+	// The body of an import reaches the handler as it was sent, not read
+	// into memory by the validation.
+	streamed := func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Body.(*streamedBody); !ok {
+			http.Error(w, "import body was buffered", http.StatusInternalServerError)
+			return
+		}
+		echo(w, r)
+	}
+
+	r := chi.NewRouter()
+	r.Route("/url", func(r chi.Router) {
+		r.Use(validate)
+		r.Get("/", echo)
+		r.Post("/", echo)
+		r.Get("/audit", echo)
+		r.Post("/import", streamed)
+		r.Get("/{alias}/qr", echo)
+	})
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, &streamedBody{strings.NewReader(tt.body)})
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			// Rejected requests are answered like the handlers answer them.
+			require.Equal(t, tt.status, rr.Code)
+			if tt.respError != "" {
+				var resp response.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, tt.respError, resp.Error)
+				return
+			}
+			if tt.status == http.StatusOK {
+				// The handler still gets the whole body.
+				require.Equal(t, tt.body, rr.Body.String())
+			}
+		})
+	}
+}
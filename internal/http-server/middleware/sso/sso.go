@@ -13,6 +13,14 @@ type IsAdminChecker interface {
 	IsAdmin(ctx context.Context, email string) (bool, error)
 }
 
+type ctxKey struct{}
+
+// Email returns the email of the admin authenticated by IsRequestAdmin.
+func Email(ctx context.Context) string {
+	email, _ := ctx.Value(ctxKey{}).(string)
+	return email
+}
+
 func IsRequestAdmin(realm string, ssoClient IsAdminChecker, timeout time.Duration) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -39,7 +47,7 @@ func IsRequestAdmin(realm string, ssoClient IsAdminChecker, timeout time.Duratio
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, email)))
 		})
 	}
 }
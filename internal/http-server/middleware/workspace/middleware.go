@@ -0,0 +1,120 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	resp "url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/middleware/sso"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// Header selects the workspace of an admin who is a member of several.
+const Header = "X-Workspace"
+
+type ctxKey struct{}
+
+var log *slog.Logger = sl.GetLogger()
+
+// FromContext returns the workspace set by Resolve or Set, the default
+// workspace if there is none.
+func FromContext(ctx context.Context) Workspace {
+	w, _ := ctx.Value(ctxKey{}).(Workspace)
+	return w
+}
+
+// Resolve scopes admin requests to the workspace of the admin authenticated
+// by sso.IsRequestAdmin. The domain query parameter selects the namespace of
+// one of the custom domains of the workspace.
+func Resolve(directory *Directory) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			workspace, err := directory.Resolve(sso.Email(r.Context()), r.Header.Get(Header))
+			if errors.Is(err, ErrAmbiguous) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			if domain := r.URL.Query().Get("domain"); domain != "" {
+				workspace, err = directory.OnDomain(workspace, domain)
+				if err != nil {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, workspace)))
+		})
+	}
+}
+
+// Set scopes the requests of a route, e.g. the redirects under /{prefix}/.
+func Set(workspace Workspace) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, workspace)))
+		})
+	}
+}
+
+// Host scopes redirects requested on a custom domain to its namespace.
+func Host(directory *Directory) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if workspace, ok := directory.ByHost(r.Host); ok {
+				r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, workspace))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type LinkWorkspacer interface {
+	LinkWorkspace(alias string) (string, error)
+}
+
+// OwnsAlias answers not found for an {alias} of another workspace. Only
+// workspaces without a prefix share aliases and need the check.
+func OwnsAlias(linkWorkspacer LinkWorkspacer) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const caller = "middleware.workspace.OwnsAlias"
+
+			workspace := FromContext(r.Context())
+			alias := workspace.Alias(chi.URLParam(r, "alias"))
+
+			owner, err := linkWorkspacer.LinkWorkspace(alias)
+			if errors.Is(err, storage.ErrURLNotFound) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err != nil {
+				log.Error("failed to get workspace of alias", slog.String("caller", caller), sl.Err(err))
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+			if owner != workspace.Name {
+				log.Info(
+					"alias belongs to another workspace",
+					slog.String("caller", caller),
+					slog.String("alias", alias),
+					slog.String("workspace", workspace.Name),
+				)
+				render.JSON(w, r, resp.Error("not found"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
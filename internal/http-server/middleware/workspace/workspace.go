@@ -0,0 +1,180 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"url-shortener/internal/storage"
+)
+
+var (
+	ErrNotMember     = errors.New("not a member of the workspace")
+	ErrAmbiguous     = errors.New("member of several workspaces")
+	ErrUnknownDomain = errors.New("domain does not belong to the workspace")
+)
+
+var prefixPattern = regexp.MustCompile("^[a-zA-Z0-9_-]+$")
+
+// Workspace is the share of the links of a team. The default workspace has
+// an empty Name and Prefix, it holds the links of admins outside of any
+// workspace. Domain is set when the workspace is used on one of its custom
+// domains.
+type Workspace struct {
+	Name   string
+	Prefix string
+	Domain string
+}
+
+// Namespace returns the namespace of the aliases of w. Aliases on a custom
+// domain and of a workspace with a prefix live in their own namespace, served
+// on the domain or under /{prefix}/{alias}.
+func (w Workspace) Namespace() string {
+	if w.Domain != "" {
+		return w.Domain
+	}
+	return w.Prefix
+}
+
+// Alias returns the key alias is stored under, see storage.Key.
+func (w Workspace) Alias(alias string) string {
+	return storage.Key(w.Namespace(), alias)
+}
+
+// Unalias returns the alias stored under key, the reverse of Alias. Keys of
+// another namespace are returned unchanged.
+func (w Workspace) Unalias(key string) string {
+	return strings.TrimPrefix(key, w.Alias(""))
+}
+
+// Path returns the path alias is served at.
+func (w Workspace) Path(alias string) string {
+	if w.Domain != "" || w.Prefix == "" {
+		return "/" + alias
+	}
+	return "/" + w.Prefix + "/" + alias
+}
+
+// Directory resolves the workspace of an admin from their SSO email.
+type Directory struct {
+	workspaces []Workspace
+	members    map[string][]Workspace
+	domains    map[string]Workspace
+}
+
+func NewDirectory() *Directory {
+	return &Directory{
+		members: make(map[string][]Workspace),
+		domains: make(map[string]Workspace),
+	}
+}
+
+// Add registers a workspace. Each of its domains is a namespace of its own,
+// so the same alias can exist on several domains.
+func (d *Directory) Add(name string, prefix string, members []string, domains []string) error {
+	const caller = "middleware.workspace.Add"
+
+	if name == "" {
+		return fmt.Errorf("%s: workspace name is empty", caller)
+	}
+	if prefix != "" && !prefixPattern.MatchString(prefix) {
+		return fmt.Errorf("%s: invalid prefix %q of workspace %s", caller, prefix, name)
+	}
+	for _, w := range d.workspaces {
+		if w.Name == name {
+			return fmt.Errorf("%s: duplicate workspace %s", caller, name)
+		}
+		if prefix != "" && w.Prefix == prefix {
+			return fmt.Errorf("%s: prefix %q is used by workspaces %s and %s", caller, prefix, w.Name, name)
+		}
+	}
+	if _, ok := d.domains[prefix]; ok {
+		return fmt.Errorf("%s: prefix %q of workspace %s is a domain", caller, prefix, name)
+	}
+
+	w := Workspace{Name: name, Prefix: prefix}
+
+	for _, domain := range domains {
+		domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+		if domain == "" || strings.ContainsAny(domain, "/:") {
+			return fmt.Errorf("%s: invalid domain %q of workspace %s", caller, domain, name)
+		}
+		if other, ok := d.domains[domain]; ok {
+			return fmt.Errorf("%s: domain %s is used by workspaces %s and %s", caller, domain, other.Name, name)
+		}
+		for _, other := range append(d.workspaces, w) {
+			if other.Prefix == domain {
+				return fmt.Errorf("%s: domain %s is the prefix of workspace %s", caller, domain, other.Name)
+			}
+		}
+		d.domains[domain] = Workspace{Name: name, Prefix: prefix, Domain: domain}
+	}
+
+	d.workspaces = append(d.workspaces, w)
+	for _, member := range members {
+		member = strings.ToLower(member)
+		d.members[member] = append(d.members[member], w)
+	}
+
+	return nil
+}
+
+// Resolve returns the workspace named name, which email must be a member of.
+// Without a name it is the only workspace of email, or the default one if
+// email is not a member of any.
+func (d *Directory) Resolve(email string, name string) (Workspace, error) {
+	const caller = "middleware.workspace.Resolve"
+
+	workspaces := d.members[strings.ToLower(email)]
+
+	if name != "" {
+		for _, w := range workspaces {
+			if w.Name == name {
+				return w, nil
+			}
+		}
+		return Workspace{}, fmt.Errorf("%s: %w: %s", caller, ErrNotMember, name)
+	}
+
+	switch len(workspaces) {
+	case 0:
+		return Workspace{}, nil
+	case 1:
+		return workspaces[0], nil
+	default:
+		return Workspace{}, fmt.Errorf("%s: %w", caller, ErrAmbiguous)
+	}
+}
+
+// OnDomain returns w used on domain, which must be one of its domains.
+func (d *Directory) OnDomain(w Workspace, domain string) (Workspace, error) {
+	const caller = "middleware.workspace.OnDomain"
+
+	res, ok := d.domains[strings.TrimSuffix(strings.ToLower(domain), ".")]
+	if !ok || res.Name != w.Name {
+		return Workspace{}, fmt.Errorf("%s: %w: %s", caller, ErrUnknownDomain, domain)
+	}
+
+	return res, nil
+}
+
+// ByHost returns the workspace the custom domain host belongs to.
+func (d *Directory) ByHost(host string) (Workspace, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	w, ok := d.domains[strings.TrimSuffix(strings.ToLower(host), ".")]
+	return w, ok
+}
+
+// Prefixed returns the workspaces with their own alias namespace.
+func (d *Directory) Prefixed() []Workspace {
+	var res []Workspace
+	for _, w := range d.workspaces {
+		if w.Prefix != "" {
+			res = append(res, w)
+		}
+	}
+	return res
+}
@@ -0,0 +1,131 @@
+package workspace
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/api/response"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectory(t *testing.T) {
+	d := NewDirectory()
+	require.NoError(t, d.Add("eng", "eng", []string{"Dev@example.com", "lead@example.com"}, nil))
+	require.NoError(t, d.Add("marketing", "", []string{"ads@example.com", "lead@example.com"}, []string{"Go.Brand.com"}))
+	require.Error(t, d.Add("eng", "", nil, nil))
+	require.Error(t, d.Add("ops", "eng", nil, nil))
+	require.Error(t, d.Add("ops", "o/ps", nil, nil))
+	require.Error(t, d.Add("ops", "", nil, []string{"go.brand.com"}))
+	require.Error(t, d.Add("ops", "", nil, []string{"eng"}))
+
+	w, err := d.Resolve("dev@example.com", "")
+	require.NoError(t, err)
+	require.Equal(t, Workspace{Name: "eng", Prefix: "eng"}, w)
+	require.Equal(t, "eng/docs", w.Alias("docs"))
+
+	w, err = d.Resolve("other@example.com", "")
+	require.NoError(t, err)
+	require.Equal(t, Workspace{}, w)
+	require.Equal(t, "docs", w.Alias("docs"))
+
+	_, err = d.Resolve("lead@example.com", "")
+	require.ErrorIs(t, err, ErrAmbiguous)
+
+	w, err = d.Resolve("lead@example.com", "marketing")
+	require.NoError(t, err)
+	require.Equal(t, "marketing", w.Name)
+
+	_, err = d.Resolve("dev@example.com", "marketing")
+	require.ErrorIs(t, err, ErrNotMember)
+
+	require.Equal(t, []Workspace{{Name: "eng", Prefix: "eng"}}, d.Prefixed())
+}
+
+func TestDirectoryDomains(t *testing.T) {
+	d := NewDirectory()
+	require.NoError(t, d.Add("brand", "brand", []string{"ads@example.com"}, []string{"go.brand.com"}))
+	require.NoError(t, d.Add("other", "", []string{"dev@example.com"}, []string{"oth.er"}))
+
+	w, ok := d.ByHost("GO.brand.com:443")
+	require.True(t, ok)
+	require.Equal(t, "go.brand.com/sale", w.Alias("sale"))
+	require.Equal(t, "/sale", w.Path("sale"))
+	require.Equal(t, "sale", w.Unalias("go.brand.com/sale"))
+	require.Equal(t, "brand/sale", w.Unalias("brand/sale"))
+
+	w, ok = d.ByHost("oth.er")
+	require.True(t, ok)
+	require.Equal(t, "oth.er/sale", w.Alias("sale"))
+
+	_, ok = d.ByHost("localhost:8081")
+	require.False(t, ok)
+
+	brand, err := d.Resolve("ads@example.com", "")
+	require.NoError(t, err)
+	require.Equal(t, "brand/sale", brand.Alias("sale"))
+	require.Equal(t, "sale", brand.Unalias("brand/sale"))
+	require.Equal(t, "/brand/sale", brand.Path("sale"))
+
+	w, err = d.OnDomain(brand, "go.brand.com")
+	require.NoError(t, err)
+	require.Equal(t, "go.brand.com/sale", w.Alias("sale"))
+
+	_, err = d.OnDomain(brand, "oth.er")
+	require.ErrorIs(t, err, ErrUnknownDomain)
+}
+
+type linkWorkspaces map[string]string
+
+func (l linkWorkspaces) LinkWorkspace(alias string) (string, error) {
+	w, ok := l[alias]
+	if !ok {
+		return "", storage.ErrURLNotFound
+	}
+	return w, nil
+}
+
+func TestOwnsAlias(t *testing.T) {
+	links := linkWorkspaces{"ads": "marketing", "home": ""}
+
+	testCases := []struct {
+		name      string
+		alias     string
+		workspace Workspace
+		passed    bool
+	}{
+		{name: "own alias", alias: "ads", workspace: Workspace{Name: "marketing"}, passed: true},
+		{name: "default workspace", alias: "home", passed: true},
+		{name: "other workspace", alias: "ads", passed: false},
+		{name: "unknown alias", alias: "missing", workspace: Workspace{Name: "marketing"}, passed: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			passed := false
+			r := chi.NewRouter()
+			r.Use(Set(tt.workspace))
+			r.Route("/{alias}", func(r chi.Router) {
+				r.Use(OwnsAlias(links))
+				r.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+					passed = true
+				})
+			})
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/"+tt.alias, nil))
+
+			require.Equal(t, tt.passed, passed)
+			if !tt.passed {
+				var resp response.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, "not found", resp.Error)
+			}
+		})
+	}
+}
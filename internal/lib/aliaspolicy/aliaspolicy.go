@@ -0,0 +1,108 @@
+package aliaspolicy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Validation tags registered by Register, see response.ValidationError.
+const (
+	TagCharset  = "alias_charset"
+	TagLength   = "alias_length"
+	TagReserved = "alias_reserved"
+)
+
+// Routes are the static top-level routes of the server and the path of its
+// OpenAPI document, aliases must not take them. The prefixes of the
+// workspaces are reserved as well.
+var Routes = []string{"url", "openapi.json"}
+
+// Policy decides which custom aliases may be used.
+type Policy struct {
+	pattern   *regexp.Regexp
+	minLength int
+	maxLength int
+
+	mu       sync.RWMutex
+	reserved map[string]struct{}
+}
+
+func New(pattern string, minLength int, maxLength int, reserved []string) (*Policy, error) {
+	const caller = "lib.aliaspolicy.New"
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	p := &Policy{
+		pattern:   re,
+		minLength: minLength,
+		maxLength: maxLength,
+		reserved:  make(map[string]struct{}),
+	}
+	p.Reserve(reserved...)
+
+	return p, nil
+}
+
+// Reserve forbids words as aliases, regardless of case.
+func (p *Policy) Reserve(words ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			p.reserved[strings.ToLower(word)] = struct{}{}
+		}
+	}
+}
+
+// Register adds the policy checks to v under TagCharset, TagLength and TagReserved.
+func (p *Policy) Register(v *validator.Validate) error {
+	const caller = "lib.aliaspolicy.Register"
+
+	checks := map[string]func(alias string) bool{
+		TagCharset:  p.validCharset,
+		TagLength:   p.validLength,
+		TagReserved: p.notReserved,
+	}
+	for tag, check := range checks {
+		err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return check(fl.Field().String())
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Policy) validCharset(alias string) bool {
+	return p.pattern.MatchString(alias)
+}
+
+func (p *Policy) validLength(alias string) bool {
+	n := utf8.RuneCountInString(alias)
+	if p.minLength > 0 && n < p.minLength {
+		return false
+	}
+	if p.maxLength > 0 && n > p.maxLength {
+		return false
+	}
+	return true
+}
+
+func (p *Policy) notReserved(alias string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	_, ok := p.reserved[strings.ToLower(alias)]
+	return !ok
+}
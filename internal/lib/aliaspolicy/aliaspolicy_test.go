@@ -0,0 +1,79 @@
+package aliaspolicy
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	testCases := []struct {
+		name  string
+		alias string
+		tag   string
+	}{
+		{
+			name:  "valid",
+			alias: "my-alias_1",
+		},
+		{
+			name:  "slash",
+			alias: "a/b",
+			tag:   TagCharset,
+		},
+		{
+			name:  "space",
+			alias: "a b",
+			tag:   TagCharset,
+		},
+		{
+			name:  "unicode",
+			alias: "алиас",
+			tag:   TagCharset,
+		},
+		{
+			name:  "too short",
+			alias: "ab",
+			tag:   TagLength,
+		},
+		{
+			name:  "too long",
+			alias: "abcdefghijk",
+			tag:   TagLength,
+		},
+		{
+			name:  "reserved",
+			alias: "URL",
+			tag:   TagReserved,
+		},
+		{
+			name:  "reserved route",
+			alias: "healthz",
+			tag:   TagReserved,
+		},
+	}
+
+	p, err := New("^[a-zA-Z0-9_-]+$", 3, 10, []string{"url"})
+	require.NoError(t, err)
+	p.Reserve("healthz")
+
+	v := validator.New()
+	require.NoError(t, p.Register(v))
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := v.Var(tt.alias, TagCharset+","+TagLength+","+TagReserved)
+			if tt.tag == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var validateErr validator.ValidationErrors
+			require.ErrorAs(t, err, &validateErr)
+			require.Equal(t, tt.tag, validateErr[0].Tag())
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"net/http"
+	"url-shortener/internal/http-server/middleware/clientip"
+	"url-shortener/internal/http-server/middleware/sso"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Event returns the event of a change made by r. It is passed to the storage
+// method making the change, which records it in the same transaction.
+func Event(r *http.Request, action string, alias string, oldValue string, newValue string) storage.AuditEvent {
+	return storage.AuditEvent{
+		Actor:      sso.Email(r.Context()),
+		Workspace:  workspace.FromContext(r.Context()).Name,
+		Action:     action,
+		Alias:      alias,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+		RequestID:  middleware.GetReqID(r.Context()),
+		RemoteAddr: remoteAddr(r),
+	}
+}
+
+// remoteAddr is the client address of r, behind trusted proxies too, or the
+// peer address as is when it cannot be parsed.
+func remoteAddr(r *http.Request) string {
+	if ip := clientip.IP(r); ip != nil {
+		return ip.String()
+	}
+	return r.RemoteAddr
+}
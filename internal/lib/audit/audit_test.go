@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/internal/http-server/middleware/clientip"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRemoteAddr(t *testing.T) {
+	resolve, err := clientip.Resolve([]string{"10.0.0.1"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{
+			name:       "peer",
+			remoteAddr: "203.0.113.7:1234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "behind a trusted proxy",
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  "203.0.113.7",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "forwarded by an untrusted peer",
+			remoteAddr: "198.51.100.1:1234",
+			forwarded:  "203.0.113.7",
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "unparsable peer",
+			remoteAddr: "pipe",
+			want:       "pipe",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodDelete, "/url/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set(clientip.Header, tt.forwarded)
+			}
+
+			var event string
+			resolve(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				event = Event(r, "delete", "test", "", "").RemoteAddr
+			})).ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Equal(t, tt.want, event)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DB looks up countries in a local MaxMind-format database, e.g. GeoLite2-Country.
+type DB struct {
+	reader *maxminddb.Reader
+}
+
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+func Open(path string) (*DB, error) {
+	const caller = "lib.geoip.Open"
+
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return &DB{reader: reader}, nil
+}
+
+func (db *DB) Close() error {
+	return db.reader.Close()
+}
+
+// Country returns the ISO 3166-1 alpha-2 code for ip, or an empty string if
+// the database does not know it.
+func (db *DB) Country(ip net.IP) (string, error) {
+	const caller = "lib.geoip.Country"
+
+	var rec record
+	if err := db.reader.Lookup(ip, &rec); err != nil {
+		return "", fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return rec.Country.ISOCode, nil
+}
@@ -0,0 +1,236 @@
+// Package linkchain checks destinations which point back at the shortener,
+// so that links do not redirect in a loop or through long chains.
+package linkchain
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/storage"
+)
+
+var (
+	ErrRedirectLoop  = errors.New("redirect loop")
+	ErrChainTooLong  = errors.New("redirect chain is too long")
+	ErrUnknownTarget = errors.New("destination is not a known alias")
+)
+
+//go:generate go run github.com/vektra/mockery/v2 --name=URLGetter
+type URLGetter interface {
+	GetURL(alias string) (storage.Link, error)
+}
+
+// Own describes the urls served by the shortener itself.
+type Own struct {
+	// Hosts serve the default namespace and the workspace prefixes, given as
+	// host, host:port or base url.
+	Hosts    []string
+	Prefixes []string
+	// Domains are custom domains, each serving a namespace of its own.
+	Domains []string
+	// DomainBaseURLs are the urls custom domains are served at besides
+	// https://{domain}, see shorturl.New.
+	DomainBaseURLs map[string]string
+}
+
+// Chains resolves the destinations of links through the aliases they point
+// at.
+type Chains struct {
+	urlGetter URLGetter
+	maxDepth  int
+	flatten   bool
+
+	hosts    map[string]struct{}
+	prefixes map[string]struct{}
+	// domains maps the hosts of custom domains to the namespaces they serve.
+	domains map[string]string
+}
+
+// New returns Chains which looks the aliases up with urlGetter. A chain may
+// go through at most maxDepth aliases, with flatten set Resolve replaces the
+// destinations with the final ones.
+func New(urlGetter URLGetter, own Own, maxDepth int, flatten bool) *Chains {
+	c := &Chains{
+		urlGetter: urlGetter,
+		maxDepth:  maxDepth,
+		flatten:   flatten,
+		hosts:     make(map[string]struct{}),
+		prefixes:  make(map[string]struct{}),
+		domains:   make(map[string]string),
+	}
+	for _, host := range own.Hosts {
+		if host = hostOf(host); host != "" {
+			c.hosts[host] = struct{}{}
+		}
+	}
+	for _, prefix := range own.Prefixes {
+		if prefix != "" {
+			c.prefixes[prefix] = struct{}{}
+		}
+	}
+	for _, domain := range own.Domains {
+		if domain = hostOf(domain); domain != "" {
+			c.domains[domain] = domain
+		}
+	}
+	for domain, baseURL := range own.DomainBaseURLs {
+		if host := hostOf(baseURL); host != "" {
+			c.domains[host] = hostOf(domain)
+		}
+	}
+	return c
+}
+
+// With returns a copy of c which sees links, keyed by alias, in place of or
+// in addition to the ones of its URLGetter. Imports check their rows with
+// it before saving them, so that rows may point at each other.
+func (c *Chains) With(links map[string]storage.Link) *Chains {
+	with := *c
+	with.urlGetter = overlay{links: links, urlGetter: c.urlGetter}
+	return &with
+}
+
+// overlay looks aliases up in links before asking urlGetter.
+type overlay struct {
+	links     map[string]storage.Link
+	urlGetter URLGetter
+}
+
+func (o overlay) GetURL(alias string) (storage.Link, error) {
+	if link, ok := o.links[alias]; ok {
+		return link, nil
+	}
+	return o.urlGetter.GetURL(alias)
+}
+
+// Resolve checks the url, the rules and the destinations of link, replacing
+// them with their final destinations if flatten is set.
+func (c *Chains) Resolve(link *storage.Link) error {
+	const caller = "lib.linkchain.Resolve"
+
+	urls := []*string{&link.URL}
+	for i := range link.Rules {
+		urls = append(urls, &link.Rules[i].URL)
+	}
+	for i := range link.Destinations {
+		urls = append(urls, &link.Destinations[i].URL)
+	}
+
+	for _, u := range urls {
+		final, err := c.Final(link.Alias, *u)
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+		if c.flatten {
+			*u = final
+		}
+	}
+
+	return nil
+}
+
+// Final follows destination while it points back at the shortener and
+// returns the final destination outside of it. The rules and destinations
+// of the aliases on the way are followed as well, a chain which comes back to
+// alias or to any alias before it is a loop.
+func (c *Chains) Final(alias string, destination string) (string, error) {
+	const caller = "lib.linkchain.Final"
+
+	final, err := c.follow(map[string]struct{}{alias: {}}, destination, c.maxDepth)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return final, nil
+}
+
+// follow resolves destination with at most depth more hops, seen holds the
+// aliases of the chain leading to it.
+func (c *Chains) follow(seen map[string]struct{}, destination string, depth int) (string, error) {
+	target, ok := c.alias(destination)
+	if !ok {
+		return destination, nil
+	}
+	if _, ok = seen[target]; ok {
+		return "", fmt.Errorf("%w: %s", ErrRedirectLoop, target)
+	}
+	if depth == 0 {
+		return "", ErrChainTooLong
+	}
+
+	link, err := c.urlGetter.GetURL(target)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrUnknownTarget, target)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	seen[target] = struct{}{}
+	defer delete(seen, target)
+
+	final, err := c.follow(seen, link.URL, depth-1)
+	if err != nil {
+		return "", err
+	}
+	for _, rule := range link.Rules {
+		if _, err = c.follow(seen, rule.URL, depth-1); err != nil {
+			return "", err
+		}
+	}
+	for _, destination := range link.Destinations {
+		if _, err = c.follow(seen, destination.URL, depth-1); err != nil {
+			return "", err
+		}
+	}
+
+	return final, nil
+}
+
+// alias reports whether destination is served by the shortener and returns
+// the key of the alias it names, see workspace.Workspace.Alias. The root and
+// the routes of aliaspolicy.Routes, e.g. the API, are not aliases.
+func (c *Chains) alias(destination string) (string, bool) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", false
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	alias, rest, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if alias == "" || slices.Contains(aliaspolicy.Routes, alias) {
+		return "", false
+	}
+
+	if domain, ok := c.domains[host]; ok {
+		return storage.Key(domain, strings.TrimSuffix(alias, "+")), true
+	}
+	if _, ok := c.hosts[host]; !ok {
+		return "", false
+	}
+	if _, ok := c.prefixes[alias]; ok {
+		prefixed, _, _ := strings.Cut(rest, "/")
+		return storage.Key(alias, strings.TrimSuffix(prefixed, "+")), true
+	}
+	return strings.TrimSuffix(alias, "+"), true
+}
+
+// hostOf returns the lower case host of a base url, host:port or host.
+func hostOf(address string) string {
+	address = strings.TrimSpace(address)
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return ""
+		}
+		address = u.Host
+	}
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	return strings.TrimSuffix(strings.ToLower(address), ".")
+}
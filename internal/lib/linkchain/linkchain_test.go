@@ -0,0 +1,204 @@
+package linkchain
+
+import (
+	"testing"
+	"url-shortener/internal/lib/linkchain/mocks"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinal(t *testing.T) {
+	links := map[string]string{
+		"ext":               "https://ya.ru",
+		"hop1":              "https://sho.rt/ext",
+		"hop2":              "https://sho.rt/hop1",
+		"hop3":              "https://sho.rt/hop2",
+		"cycle":             "https://sho.rt/new",
+		"eng/x":             "https://sho.rt/ext",
+		"go.brand.com/sale": "https://sho.rt/eng/x",
+	}
+
+	testCases := []struct {
+		name        string
+		destination string
+		final       string
+		err         error
+	}{
+		{
+			name:        "external destination",
+			destination: "https://ya.ru/page",
+			final:       "https://ya.ru/page",
+		},
+		{
+			name:        "chain",
+			destination: "https://SHO.RT/hop2",
+			final:       "https://ya.ru",
+		},
+		{
+			name:        "self reference",
+			destination: "https://sho.rt/new",
+			err:         ErrRedirectLoop,
+		},
+		{
+			name:        "self reference preview",
+			destination: "https://sho.rt/new+",
+			err:         ErrRedirectLoop,
+		},
+		{
+			name:        "loop through another alias",
+			destination: "https://sho.rt/cycle",
+			err:         ErrRedirectLoop,
+		},
+		{
+			name:        "too long",
+			destination: "https://sho.rt/hop3",
+			err:         ErrChainTooLong,
+		},
+		{
+			name:        "workspace prefix",
+			destination: "https://sho.rt/eng/x",
+			final:       "https://ya.ru",
+		},
+		{
+			name:        "custom domain",
+			destination: "https://go.brand.com/sale",
+			final:       "https://ya.ru",
+		},
+		{
+			name:        "unknown alias",
+			destination: "https://sho.rt/missing",
+			err:         ErrUnknownTarget,
+		},
+		{
+			name:        "api route",
+			destination: "https://sho.rt/url/x/qr?format=svg",
+			final:       "https://sho.rt/url/x/qr?format=svg",
+		},
+		{
+			name:        "openapi document",
+			destination: "https://sho.rt/openapi.json",
+			final:       "https://sho.rt/openapi.json",
+		},
+		{
+			name:        "root",
+			destination: "https://sho.rt/",
+			final:       "https://sho.rt/",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			for alias, u := range links {
+				urlGetterMock.On("GetURL", alias).Return(storage.Link{Alias: alias, URL: u}, nil).Maybe()
+			}
+			urlGetterMock.On("GetURL", "missing").Return(storage.Link{}, storage.ErrURLNotFound).Maybe()
+
+			chains := New(urlGetterMock, Own{
+				Hosts:    []string{"sho.rt", "localhost:8081"},
+				Prefixes: []string{"eng"},
+				Domains:  []string{"go.brand.com"},
+			}, 3, false)
+
+			final, err := chains.Final("new", tt.destination)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.final, final)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "ext").Return(storage.Link{Alias: "ext", URL: "https://ya.ru"}, nil).Maybe()
+	urlGetterMock.On("GetURL", "split").Return(storage.Link{
+		Alias: "split",
+		URL:   "https://ya.ru",
+		Destinations: []storage.Destination{
+			{URL: "https://ya.ru/a", Weight: 1},
+			{URL: "https://sho.rt/new", Weight: 1},
+		},
+	}, nil).Maybe()
+
+	own := Own{Hosts: []string{"sho.rt"}}
+
+	link := storage.Link{
+		Alias: "new",
+		URL:   "https://ya.ru/page",
+		Rules: []storage.Rule{{Device: "ios", URL: "https://sho.rt/ext"}},
+	}
+	require.NoError(t, New(urlGetterMock, own, 3, true).Resolve(&link))
+	require.Equal(t, "https://ya.ru", link.Rules[0].URL)
+
+	link = storage.Link{
+		Alias: "new",
+		URL:   "https://ya.ru/page",
+		Rules: []storage.Rule{{Device: "ios", URL: "https://sho.rt/new"}},
+	}
+	require.ErrorIs(t, New(urlGetterMock, own, 3, false).Resolve(&link), ErrRedirectLoop)
+
+	link = storage.Link{
+		Alias: "new",
+		URL:   "https://ya.ru/page",
+		Destinations: []storage.Destination{
+			{URL: "https://ya.ru/b", Weight: 1},
+			{URL: "https://sho.rt/split", Weight: 1},
+		},
+	}
+	require.ErrorIs(t, New(urlGetterMock, own, 3, false).Resolve(&link), ErrRedirectLoop)
+}
+
+func TestWith(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "ext").Return(storage.Link{Alias: "ext", URL: "https://ya.ru"}, nil).Maybe()
+	urlGetterMock.On("GetURL", "b").Return(storage.Link{}, storage.ErrURLNotFound).Maybe()
+
+	chains := New(urlGetterMock, Own{Hosts: []string{"sho.rt"}}, 3, false)
+
+	_, err := chains.Final("a", "https://sho.rt/b")
+	require.ErrorIs(t, err, ErrUnknownTarget)
+
+	with := chains.With(map[string]storage.Link{
+		"b": {Alias: "b", URL: "https://sho.rt/ext"},
+		"c": {Alias: "c", URL: "https://sho.rt/a"},
+	})
+	final, err := with.Final("a", "https://sho.rt/b")
+	require.NoError(t, err)
+	require.Equal(t, "https://ya.ru", final)
+
+	_, err = with.Final("a", "https://sho.rt/c")
+	require.ErrorIs(t, err, ErrRedirectLoop)
+}
+
+func TestOwn(t *testing.T) {
+	chains := New(nil, Own{
+		Hosts:          []string{"localhost:8081", "https://Sho.rt/"},
+		Prefixes:       []string{"eng"},
+		Domains:        []string{"go.brand.com"},
+		DomainBaseURLs: map[string]string{"go.brand.com": "http://links.brand.com:8080"},
+	}, 3, false)
+
+	testCases := []struct {
+		destination string
+		alias       string
+		own         bool
+	}{
+		{destination: "http://localhost:8081/a", alias: "a", own: true},
+		{destination: "https://sho.rt/a", alias: "a", own: true},
+		{destination: "https://sho.rt/eng/a", alias: "eng/a", own: true},
+		{destination: "https://go.brand.com/sale", alias: "go.brand.com/sale", own: true},
+		{destination: "http://links.brand.com:8080/sale", alias: "go.brand.com/sale", own: true},
+		{destination: "https://ya.ru/a"},
+	}
+	for _, tt := range testCases {
+		alias, ok := chains.alias(tt.destination)
+		require.Equal(t, tt.own, ok, tt.destination)
+		require.Equal(t, tt.alias, alias, tt.destination)
+	}
+}
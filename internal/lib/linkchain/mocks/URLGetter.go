@@ -0,0 +1,56 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	storage "url-shortener/internal/storage"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// URLGetter is an autogenerated mock type for the URLGetter type
+type URLGetter struct {
+	mock.Mock
+}
+
+// GetURL provides a mock function with given fields: alias
+func (_m *URLGetter) GetURL(alias string) (storage.Link, error) {
+	ret := _m.Called(alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetURL")
+	}
+
+	var r0 storage.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (storage.Link, error)); ok {
+		return rf(alias)
+	}
+	if rf, ok := ret.Get(0).(func(string) storage.Link); ok {
+		r0 = rf(alias)
+	} else {
+		r0 = ret.Get(0).(storage.Link)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewURLGetter creates a new instance of URLGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLGetter {
+	mock := &URLGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,256 @@
+package linkfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"url-shortener/internal/api/response"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/lib/linkchain"
+	"url-shortener/internal/lib/random"
+	"url-shortener/internal/lib/urlpolicy"
+	"url-shortener/internal/storage"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictFail      = "fail"
+)
+
+const (
+	ResultImported    = "imported"
+	ResultOverwritten = "overwritten"
+	ResultSkipped     = "skipped"
+	ResultFailed      = "failed"
+)
+
+var (
+	ErrConflict = errors.New("alias already exists")
+	ErrRead     = errors.New("failed to read rows")
+)
+
+type Result struct {
+	Row      int    `json:"row"`
+	Alias    string `json:"alias,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+	Result   string `json:"result"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Imported    int      `json:"imported"`
+	Overwritten int      `json:"overwritten"`
+	Skipped     int      `json:"skipped"`
+	Failed      int      `json:"failed"`
+	Rows        []Result `json:"rows"`
+	// Saved are the links imported or overwritten.
+	Saved []storage.Link `json:"-"`
+}
+
+func (r *Report) add(result Result) {
+	switch result.Result {
+	case ResultImported:
+		r.Imported++
+	case ResultOverwritten:
+		r.Overwritten++
+	case ResultSkipped:
+		r.Skipped++
+	case ResultFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+// Importer saves rows into a workspace. Validate must know the url and
+// alias policy tags used by Row.
+type Importer struct {
+	Validate *validator.Validate
+	// Policy checks the networks of the urls of all rows, see
+	// urlpolicy.Policy.CheckNetworks.
+	Policy *urlpolicy.Policy
+	// Chains checks the destinations of the rows, which may point at the
+	// aliases of other rows.
+	Chains      *linkchain.Chains
+	AliasLength int
+	Workspace   workspace.Workspace
+	Owner       string
+	// OnConflict is ConflictSkip, ConflictOverwrite or ConflictFail.
+	OnConflict string
+	// Event is recorded for every link saved, with its alias and url.
+	Event storage.AuditEvent
+}
+
+// Batch holds the rows read by Prepare, in order.
+type Batch struct {
+	rows []prepared
+}
+
+// prepared is a row which failed with result, or a link to save.
+type prepared struct {
+	result Result
+	link   storage.Link
+}
+
+// Prepare reads and checks every row, without touching the storage but to
+// look up the aliases the rows point at. It stops with ErrRead if rows
+// fails other than on a single row.
+func (i Importer) Prepare(rows Reader) (Batch, error) {
+	const caller = "lib.linkfile.Prepare"
+
+	var batch Batch
+	links := make(map[string]storage.Link)
+
+	for n := 1; ; n++ {
+		row, err := rows.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			batch.fail(Result{Row: n, Result: ResultFailed, Error: "failed to parse row"})
+			continue
+		}
+		if err != nil {
+			return Batch{}, fmt.Errorf("%s: %w: %w", caller, ErrRead, err)
+		}
+
+		if err = i.Validate.Struct(row); err != nil {
+			var validateErr validator.ValidationErrors
+			if !errors.As(err, &validateErr) {
+				return Batch{}, fmt.Errorf("%s: %w", caller, err)
+			}
+			batch.fail(Result{Row: n, Alias: row.Alias, Result: ResultFailed, Error: response.ValidationError(validateErr).Error})
+			continue
+		}
+		expiresAt, err := parseExpiry(row.ExpiresAt)
+		if err != nil {
+			batch.fail(Result{Row: n, Alias: row.Alias, Result: ResultFailed, Error: "field ExpiresAt is not a valid time"})
+			continue
+		}
+
+		alias := row.Alias
+		if alias == "" {
+			alias = random.NewRandomString(i.AliasLength)
+		}
+		link := storage.Link{
+			Alias:     i.Workspace.Alias(alias),
+			URL:       row.URL,
+			Workspace: i.Workspace.Name,
+			ExpiresAt: expiresAt,
+			Folder:    row.Folder,
+			Tags:      row.Tags,
+			Owner:     i.Owner,
+		}
+		batch.rows = append(batch.rows, prepared{result: Result{Row: n, Alias: alias, Result: ResultImported}, link: link})
+	}
+
+	// The hosts of all rows are resolved together.
+	var urls []string
+	var checked []*prepared
+	for j := range batch.rows {
+		if row := &batch.rows[j]; row.result.Result != ResultFailed {
+			urls = append(urls, row.link.URL)
+			checked = append(checked, row)
+		}
+	}
+	for j, err := range i.Policy.CheckNetworks(urls) {
+		row := checked[j]
+		if err != nil {
+			row.result.Result = ResultFailed
+			row.result.Error = "field URL points to a private network"
+			continue
+		}
+		links[row.link.Alias] = row.link
+	}
+
+	// The rows are checked once all are read, a row may point at a later one.
+	chains := i.Chains.With(links)
+	for j := range batch.rows {
+		row := &batch.rows[j]
+		if row.result.Result == ResultFailed {
+			continue
+		}
+		err := chains.Resolve(&row.link)
+		if msg := chainError(err); msg != "" {
+			row.result.Result = ResultFailed
+			row.result.Error = msg
+			continue
+		}
+		if err != nil {
+			return Batch{}, fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	return batch, nil
+}
+
+func (b *Batch) fail(result Result) {
+	b.rows = append(b.rows, prepared{result: result})
+}
+
+// chainError returns the error reported for a row failing the chain check
+// with err, empty if err is not such a failure.
+func chainError(err error) string {
+	switch {
+	case errors.Is(err, linkchain.ErrRedirectLoop):
+		return "url creates a redirect loop"
+	case errors.Is(err, linkchain.ErrChainTooLong):
+		return "url redirect chain is too long"
+	case errors.Is(err, linkchain.ErrUnknownTarget):
+		return "url points to an unknown alias"
+	}
+	return ""
+}
+
+// Save saves the rows of batch into imp and reports the result of each. It
+// stops with ErrConflict on the first taken alias if OnConflict is
+// ConflictFail. Committing imp is up to the caller.
+func (i Importer) Save(imp storage.Import, batch Batch) (Report, error) {
+	const caller = "lib.linkfile.Save"
+
+	report := Report{Rows: []Result{}}
+
+	for _, row := range batch.rows {
+		result, link := row.result, row.link
+		if result.Result == ResultFailed {
+			report.add(result)
+			continue
+		}
+
+		owner, err := imp.LinkWorkspace(link.Alias)
+		exists := err == nil
+		if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+			return report, fmt.Errorf("%s: %w", caller, err)
+		}
+
+		if exists {
+			switch {
+			case i.OnConflict == ConflictFail:
+				report.add(Result{Row: result.Row, Alias: result.Alias, Result: ResultFailed, Error: ErrConflict.Error()})
+				return report, fmt.Errorf("%s: %w in row %d", caller, ErrConflict, result.Row)
+			case i.OnConflict == ConflictSkip:
+				report.add(Result{Row: result.Row, Alias: result.Alias, Result: ResultSkipped})
+				continue
+			case owner != i.Workspace.Name:
+				report.add(Result{Row: result.Row, Alias: result.Alias, Result: ResultFailed, Error: "alias belongs to another workspace"})
+				continue
+			}
+			result.Result = ResultOverwritten
+		}
+
+		// The url the link replaces is filled in by the storage.
+		event := i.Event
+		event.Alias = link.Alias
+		event.NewValue = link.URL
+		if err = imp.SaveURL(link, exists, event); err != nil {
+			return report, fmt.Errorf("%s: %w", caller, err)
+		}
+		report.add(result)
+		report.Saved = append(report.Saved, link)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,242 @@
+package linkfile
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"url-shortener/internal/http-server/middleware/workspace"
+	"url-shortener/internal/storage"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+var columns = []string{"alias", "url", "tags", "folder", "expires_at"}
+
+// Row is a link in an import or export. In CSV the tags are a single
+// comma separated column. The network of URL is checked for all rows at
+// once by Importer.Prepare, not by a tag.
+type Row struct {
+	Alias  string   `json:"alias,omitempty" validate:"omitempty,alias_charset,alias_length,alias_reserved"`
+	URL    string   `json:"url" validate:"required,url,allowed_scheme,allowed_domain"`
+	Tags   []string `json:"tags,omitempty" validate:"omitempty,max=32,dive,required,max=64"`
+	Folder string   `json:"folder,omitempty" validate:"omitempty,max=128"`
+	// ExpiresAt is an RFC 3339 time or a date, which expires at midnight UTC.
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// NewRow returns the row of a link of ws.
+func NewRow(ws workspace.Workspace, link storage.Link) Row {
+	row := Row{
+		Alias:  ws.Unalias(link.Alias),
+		URL:    link.URL,
+		Tags:   link.Tags,
+		Folder: link.Folder,
+	}
+	if !link.ExpiresAt.IsZero() {
+		row.ExpiresAt = link.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return row
+}
+
+// parseExpiry reads the ExpiresAt of a row, zero if it is empty.
+func parseExpiry(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// RowError is a row that could not be parsed, reading goes on with the next.
+type RowError struct {
+	err error
+}
+
+func (e *RowError) Error() string {
+	return e.err.Error()
+}
+
+func (e *RowError) Unwrap() error {
+	return e.err
+}
+
+type Reader interface {
+	// Read returns the next row, io.EOF after the last one.
+	Read() (Row, error)
+}
+
+type Writer interface {
+	Write(row Row) error
+	Flush() error
+}
+
+// CheckFormat returns an error unless format is FormatCSV or FormatJSONL.
+func CheckFormat(format string) error {
+	if format != FormatCSV && format != FormatJSONL {
+		return fmt.Errorf("lib.linkfile.CheckFormat: unknown format %q", format)
+	}
+	return nil
+}
+
+func NewReader(format string, r io.Reader) (Reader, error) {
+	if format == FormatCSV {
+		return newCSVReader(r)
+	}
+	return newJSONLReader(r), nil
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	if format == FormatCSV {
+		return newCSVWriter(w)
+	}
+	return &jsonlWriter{w: bufio.NewWriter(w)}, nil
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	const caller = "lib.linkfile.newCSVReader"
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read header: %w", caller, err)
+	}
+
+	res := &csvReader{r: cr, columns: make(map[string]int, len(header))}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "alias", "url", "tags", "folder", "expires_at":
+			res.columns[column] = i
+		default:
+			return nil, fmt.Errorf("%s: unknown column %q", caller, column)
+		}
+	}
+	if _, ok := res.columns["url"]; !ok {
+		return nil, fmt.Errorf("%s: url column is missing", caller)
+	}
+
+	return res, nil
+}
+
+func (c *csvReader) Read() (Row, error) {
+	record, err := c.r.Read()
+	if errors.Is(err, io.EOF) {
+		return Row{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Row{}, &RowError{err: err}
+	}
+	if err != nil {
+		return Row{}, err
+	}
+
+	field := func(column string) string {
+		i, ok := c.columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row := Row{
+		Alias:     field("alias"),
+		URL:       field("url"),
+		Folder:    field("folder"),
+		ExpiresAt: field("expires_at"),
+	}
+	for _, tag := range strings.Split(field("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			row.Tags = append(row.Tags, tag)
+		}
+	}
+
+	return row, nil
+}
+
+type jsonlReader struct {
+	s *bufio.Scanner
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return &jsonlReader{s: s}
+}
+
+func (j *jsonlReader) Read() (Row, error) {
+	for j.s.Scan() {
+		line := strings.TrimSpace(j.s.Text())
+		if line == "" {
+			continue
+		}
+
+		var row Row
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			return Row{}, &RowError{err: err}
+		}
+		return row, nil
+	}
+	if err := j.s.Err(); err != nil {
+		return Row{}, err
+	}
+	return Row{}, io.EOF
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) Write(row Row) error {
+	return c.w.Write([]string{row.Alias, row.URL, strings.Join(row.Tags, ","), row.Folder, row.ExpiresAt})
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlWriter struct {
+	w *bufio.Writer
+}
+
+func (j *jsonlWriter) Write(row Row) error {
+	line, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if _, err = j.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
@@ -0,0 +1,61 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheEviction(t *testing.T) {
+	c := New[string, int](2)
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+
+	c.Set("c", 3, 0)
+
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("expected b to be evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("got %d %v, want 1 true", v, ok)
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("got %d %v, want 3 true", v, ok)
+	}
+	if c.Len() != 2 {
+		t.Errorf("got len %d, want 2", c.Len())
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := time.Now()
+	c := New[string, int](10)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, 0)
+
+	now = now.Add(time.Minute)
+
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("expected a to be expired")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Errorf("expected b to never expire")
+	}
+}
+
+func TestCacheRemove(t *testing.T) {
+	c := New[string, int](10)
+
+	c.Set("a", 1, 0)
+	c.Remove("a")
+
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("expected a to be removed")
+	}
+}
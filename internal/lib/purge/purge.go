@@ -0,0 +1,41 @@
+package purge
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	sl "url-shortener/pkg/logger/slog"
+)
+
+type Purger interface {
+	PurgeDeleted(before time.Time, actor string) (int64, error)
+}
+
+// Actor is recorded in the audit log for the links purged by Run.
+const Actor = "purge"
+
+// Run removes links deleted longer than retention ago every interval,
+// until ctx is done.
+func Run(ctx context.Context, log *slog.Logger, purger Purger, retention time.Duration, interval time.Duration) {
+	const caller = "lib.purge.Run"
+
+	log = log.With(slog.String("caller", caller))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := purger.PurgeDeleted(time.Now().Add(-retention), Actor)
+		if err != nil {
+			log.Error("failed to purge deleted urls", sl.Err(err))
+		} else if purged > 0 {
+			log.Info("purged deleted urls", slog.Int64("count", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
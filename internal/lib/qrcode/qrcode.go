@@ -0,0 +1,112 @@
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+var ErrTooSmall = errors.New("size is too small for the code")
+
+var levels = map[string]qr.Level{
+	"L": qr.L,
+	"M": qr.M,
+	"Q": qr.Q,
+	"H": qr.H,
+}
+
+// Code is a QR code with a quiet zone of Margin modules around it.
+type Code struct {
+	code   *qr.Code
+	Margin int
+}
+
+// Encode encodes text with the error correction level L, M, Q or H.
+func Encode(text string, level string, margin int) (*Code, error) {
+	const caller = "lib.qrcode.Encode"
+
+	l, ok := levels[strings.ToUpper(level)]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown error correction level %q", caller, level)
+	}
+
+	code, err := qr.Encode(text, l)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return &Code{code: code, Margin: margin}, nil
+}
+
+// Modules returns the number of modules on a side, margin included.
+func (c *Code) Modules() int {
+	return c.code.Size + 2*c.Margin
+}
+
+func (c *Code) black(x, y int) bool {
+	return c.code.Black(x-c.Margin, y-c.Margin)
+}
+
+// PNG writes the code scaled to whole pixels per module, as large as fits
+// into size pixels.
+func (c *Code) PNG(w io.Writer, size int) error {
+	const caller = "lib.qrcode.PNG"
+
+	scale := size / c.Modules()
+	if scale < 1 {
+		return fmt.Errorf("%s: %w", caller, ErrTooSmall)
+	}
+
+	side := c.Modules() * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Modules(); y++ {
+		for x := 0; x < c.Modules(); x++ {
+			if !c.black(x, y) {
+				continue
+			}
+			for py := y * scale; py < (y+1)*scale; py++ {
+				for px := x * scale; px < (x+1)*scale; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// SVG writes the code as a size by size pixels image.
+func (c *Code) SVG(w io.Writer, size int) error {
+	const caller = "lib.qrcode.SVG"
+
+	var b strings.Builder
+	fmt.Fprintf(&b,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, c.Modules(), c.Modules(),
+	)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, c.Modules(), c.Modules())
+	for y := 0; y < c.Modules(); y++ {
+		for x := 0; x < c.Modules(); x++ {
+			if c.black(x, y) {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPNG(t *testing.T) {
+	code, err := Encode("https://sho.rt/test", "m", 4)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, code.PNG(&buf, 256))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	side := img.Bounds().Dx()
+	require.LessOrEqual(t, side, 256)
+	require.Zero(t, side%code.Modules())
+
+	scale := side / code.Modules()
+	r, _, _, _ := img.At(0, 0).RGBA()
+	require.Equal(t, uint32(0xffff), r, "margin is white")
+	r, _, _, _ = img.At(4*scale, 4*scale).RGBA()
+	require.Zero(t, r, "finder pattern is black")
+
+	require.ErrorIs(t, code.PNG(&buf, 10), ErrTooSmall)
+}
+
+func TestSVG(t *testing.T) {
+	code, err := Encode("https://sho.rt/test", "H", 0)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, code.SVG(&buf, 300))
+
+	svg := buf.String()
+	require.True(t, strings.HasPrefix(svg, "<svg"))
+	require.Contains(t, svg, `width="300"`)
+	require.Contains(t, svg, "M0 0h1v1h-1z")
+}
+
+func TestEncodeLevel(t *testing.T) {
+	_, err := Encode("https://sho.rt/test", "X", 4)
+	require.Error(t, err)
+}
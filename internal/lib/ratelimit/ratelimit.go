@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type counter struct {
+	failures int
+	start    time.Time
+}
+
+// Limiter blocks a key after limit failures within a fixed window.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	counters  map[string]*counter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:    limit,
+		window:   window,
+		counters: make(map[string]*counter),
+		now:      time.Now,
+	}
+}
+
+// Allow reports whether key has an attempt left in the current window and,
+// if so, counts the attempt as a failure under the same lock, so that
+// parallel attempts can't all pass before any of them fails. Give back the
+// attempt with Release when it succeeds.
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	c, ok := l.counters[key]
+	if !ok || now.Sub(c.start) >= l.window {
+		c = &counter{start: now}
+		l.counters[key] = c
+	}
+	if c.failures >= l.limit {
+		return false
+	}
+	c.failures++
+
+	return true
+}
+
+// Release gives back an attempt taken by Allow. Only that attempt is given
+// back, a success doesn't clear the failures of others guessing the same key.
+func (l *Limiter) Release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.counters[key]
+	if !ok || l.now().Sub(c.start) >= l.window {
+		return
+	}
+	if c.failures--; c.failures <= 0 {
+		delete(l.counters, key)
+	}
+}
+
+// sweep drops expired counters, at most once per window.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+
+	for key, c := range l.counters {
+		if now.Sub(c.start) >= l.window {
+			delete(l.counters, key)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	if !l.Allow("a") {
+		t.Fatalf("expected a to be allowed")
+	}
+	if !l.Allow("a") {
+		t.Fatalf("expected a to be allowed after 1 failure")
+	}
+	if l.Allow("a") {
+		t.Fatalf("expected a to be blocked after 2 failures")
+	}
+	if !l.Allow("b") {
+		t.Fatalf("expected b to be allowed")
+	}
+
+	now = now.Add(time.Minute)
+	if !l.Allow("a") {
+		t.Fatalf("expected a to be allowed after the window")
+	}
+}
+
+func TestLimiterRelease(t *testing.T) {
+	now := time.Now()
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	l.Allow("a")
+	l.Allow("a")
+	l.Release("a")
+	if !l.Allow("a") {
+		t.Fatalf("expected a to be allowed after a release")
+	}
+	if l.Allow("a") {
+		t.Fatalf("expected a release to give back a single attempt")
+	}
+}
+
+func TestLimiterConcurrent(t *testing.T) {
+	const limit = 5
+
+	l := New(limit, time.Minute)
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int32
+	)
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.Allow("a") {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != limit {
+		t.Fatalf("expected %d attempts to be allowed, got %d", limit, got)
+	}
+}
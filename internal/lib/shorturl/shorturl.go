@@ -0,0 +1,53 @@
+package shorturl
+
+import (
+	"net/http"
+	"strings"
+	"url-shortener/internal/storage"
+)
+
+// Builder turns stored aliases into the public urls they are served at.
+type Builder struct {
+	baseURL string
+	domains map[string]string
+}
+
+// New returns a Builder for baseURL, the public url of the shortener. An
+// empty baseURL is taken from the request. Aliases on a custom domain are
+// served at https://{domain}, unless domainBaseURLs has another url for it.
+func New(baseURL string, domains []string, domainBaseURLs map[string]string) *Builder {
+	b := &Builder{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		domains: make(map[string]string, len(domains)),
+	}
+	for _, domain := range domains {
+		domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+		b.domains[domain] = "https://" + domain
+	}
+	for domain, domainBaseURL := range domainBaseURLs {
+		b.domains[strings.TrimSuffix(strings.ToLower(domain), ".")] = strings.TrimSuffix(domainBaseURL, "/")
+	}
+	return b
+}
+
+// URL returns the short url of alias, the key it is stored under, see
+// workspace.Workspace.Alias.
+func (b *Builder) URL(r *http.Request, alias string) string {
+	namespace, rest := storage.SplitKey(alias)
+	if domainBaseURL, ok := b.domains[namespace]; ok {
+		return domainBaseURL + "/" + rest
+	}
+	return b.base(r) + "/" + alias
+}
+
+func (b *Builder) base(r *http.Request) string {
+	if b.baseURL != "" {
+		return b.baseURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
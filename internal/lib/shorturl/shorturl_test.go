@@ -0,0 +1,27 @@
+package shorturl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURL(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/url", nil)
+	req.Host = "localhost:8081"
+
+	b := New("https://sho.rt/", []string{"go.brand.com", "go.other.com"}, map[string]string{
+		"go.other.com": "http://go.other.com:8080/",
+	})
+	require.Equal(t, "https://sho.rt/test", b.URL(req, "test"))
+	require.Equal(t, "https://sho.rt/eng/test", b.URL(req, "eng/test"))
+	require.Equal(t, "https://go.brand.com/sale", b.URL(req, "go.brand.com/sale"))
+	require.Equal(t, "http://go.other.com:8080/sale", b.URL(req, "go.other.com/sale"))
+
+	b = New("", nil, nil)
+	require.Equal(t, "http://localhost:8081/test", b.URL(req, "test"))
+	req.Header.Set("X-Forwarded-Proto", "https")
+	require.Equal(t, "https://localhost:8081/test", b.URL(req, "test"))
+}
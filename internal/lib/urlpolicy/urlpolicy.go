@@ -0,0 +1,349 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrSchemeNotAllowed = errors.New("scheme is not allowed")
+	ErrDomainNotAllowed = errors.New("domain is not allowed")
+	ErrPrivateNetwork   = errors.New("destination is in a private network")
+)
+
+// Validation tags registered by Register, see response.ValidationError.
+const (
+	TagScheme  = "allowed_scheme"
+	TagDomain  = "allowed_domain"
+	TagNetwork = "public_network"
+)
+
+// Policy decides which destination urls may be shortened.
+type Policy struct {
+	schemes       []string
+	allowed       []string
+	denied        []string
+	blockPrivate  bool
+	lookupTimeout time.Duration
+	lookupIP      func(ctx context.Context, host string) ([]net.IP, error)
+}
+
+// New creates a Policy. Domains may contain wildcards, e.g. *.example.com,
+// an empty allowed list allows every domain that is not denied. Names are
+// resolved for the private network check within lookupTimeout.
+func New(
+	schemes []string,
+	allowedDomains []string,
+	deniedDomains []string,
+	blockPrivate bool,
+	lookupTimeout time.Duration,
+) *Policy {
+	return &Policy{
+		schemes:       lower(schemes),
+		allowed:       lower(allowedDomains),
+		denied:        lower(deniedDomains),
+		blockPrivate:  blockPrivate,
+		lookupTimeout: lookupTimeout,
+		lookupIP: func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		},
+	}
+}
+
+// Register adds the policy checks to v under TagScheme, TagDomain and TagNetwork.
+func (p *Policy) Register(v *validator.Validate) error {
+	const caller = "lib.urlpolicy.Register"
+
+	checks := map[string]func(u *url.URL) error{
+		TagScheme:  p.checkScheme,
+		TagDomain:  p.checkDomain,
+		TagNetwork: p.checkNetwork,
+	}
+	for tag, check := range checks {
+		err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			u, err := url.Parse(fl.Field().String())
+			if err != nil {
+				return false
+			}
+			return check(u) == nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	return nil
+}
+
+// Check returns the first rule rawURL violates.
+func (p *Policy) Check(rawURL string) error {
+	const caller = "lib.urlpolicy.Check"
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	for _, check := range []func(u *url.URL) error{p.checkScheme, p.checkDomain, p.checkNetwork} {
+		if err = check(u); err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	return nil
+}
+
+// concurrentLookups limits the hosts CheckNetworks resolves at once.
+const concurrentLookups = 16
+
+// CheckNetworks runs the private network check of TagNetwork for many urls,
+// returning the error of each in order. Every host is resolved once and up
+// to concurrentLookups hosts at a time, so that a batch is not held up by
+// one lookup after another.
+func (p *Policy) CheckNetworks(rawURLs []string) []error {
+	const caller = "lib.urlpolicy.CheckNetworks"
+
+	errs := make([]error, len(rawURLs))
+	hosts := make(map[string]*url.URL)
+	for i, rawURL := range rawURLs {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			errs[i] = fmt.Errorf("%s: %w", caller, err)
+			continue
+		}
+		hosts[strings.ToLower(u.Hostname())] = u
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	hostErrs := make(map[string]error, len(hosts))
+	sem := make(chan struct{}, concurrentLookups)
+	for host, u := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := p.checkNetwork(u)
+			mu.Lock()
+			hostErrs[host] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for i, rawURL := range rawURLs {
+		if errs[i] != nil {
+			continue
+		}
+		u, _ := url.Parse(rawURL)
+		if err := hostErrs[strings.ToLower(u.Hostname())]; err != nil {
+			errs[i] = fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	return errs
+}
+
+func (p *Policy) checkScheme(u *url.URL) error {
+	if len(p.schemes) == 0 {
+		return nil
+	}
+	scheme := strings.ToLower(u.Scheme)
+	for _, allowed := range p.schemes {
+		if scheme == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrSchemeNotAllowed, u.Scheme)
+}
+
+func (p *Policy) checkDomain(u *url.URL) error {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if matchAny(p.denied, host) {
+		return fmt.Errorf("%w: %s", ErrDomainNotAllowed, host)
+	}
+	if len(p.allowed) > 0 && !matchAny(p.allowed, host) {
+		return fmt.Errorf("%w: %s", ErrDomainNotAllowed, host)
+	}
+	return nil
+}
+
+func (p *Policy) checkNetwork(u *url.URL) error {
+	if !p.blockPrivate {
+		return nil
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrPrivateNetwork, host)
+	}
+
+	ips := []net.IP{parseIP(host)}
+	if ips[0] == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), p.lookupTimeout)
+		defer cancel()
+
+		resolved, err := p.lookupIP(ctx, host)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			// Names that do not exist are let through, they cannot point
+			// anywhere yet.
+			return nil
+		}
+		if err != nil {
+			// A failed or timed out lookup may hide a private address.
+			return fmt.Errorf("%w: %s: %w", ErrPrivateNetwork, host, err)
+		}
+		ips = resolved
+	}
+
+	for _, ip := range ips {
+		if isPrivate(ip) {
+			return fmt.Errorf("%w: %s", ErrPrivateNetwork, host)
+		}
+	}
+
+	return nil
+}
+
+// parseIP also accepts the forms of an IPv4 address inet_aton reads and
+// browsers and curl resolve as well: fewer than four parts, e.g. 127.1, and
+// octal or hex parts, e.g. 0177.0.0.1 or 0x7f000001.
+func parseIP(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return nil
+	}
+	values := make([]uint64, len(parts))
+	for i, part := range parts {
+		n, ok := parseIPPart(part)
+		if !ok {
+			return nil
+		}
+		values[i] = n
+	}
+
+	// Every part but the last is a single byte, the last one fills the
+	// remaining bytes.
+	var n uint64
+	for _, v := range values[:len(values)-1] {
+		if v > 0xff {
+			return nil
+		}
+		n = n<<8 | v
+	}
+	last := values[len(values)-1]
+	bits := 8 * (5 - len(values))
+	if last >= 1<<bits {
+		return nil
+	}
+	n = n<<bits | last
+
+	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+// parseIPPart reads a decimal, 0 prefixed octal or 0x prefixed hex number.
+func parseIPPart(part string) (uint64, bool) {
+	base := 10
+	switch {
+	case len(part) > 1 && (part[:2] == "0x" || part[:2] == "0X"):
+		base = 16
+		part = part[2:]
+		if part == "" {
+			// 0x alone is zero for inet_aton.
+			return 0, true
+		}
+	case len(part) > 1 && part[0] == '0':
+		base = 8
+		part = part[1:]
+	}
+	if part == "" || strings.ContainsAny(part, "+-_") {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(part, base, 32)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// reserved are the ranges which are not reachable on the public internet,
+// besides those of the net.IP methods used by isPrivate.
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/23"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+func isPrivate(ip net.IP) bool {
+	if ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+	addr = addr.Unmap()
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func lower(values []string) []string {
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, strings.ToLower(v))
+		}
+	}
+	return res
+}
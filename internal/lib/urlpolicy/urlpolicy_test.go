@@ -0,0 +1,158 @@
+package urlpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	testCases := []struct {
+		name    string
+		url     string
+		allowed []string
+		denied  []string
+		err     error
+	}{
+		{
+			name: "public url",
+			url:  "https://ya.ru/path",
+		},
+		{
+			name: "javascript scheme",
+			url:  "javascript:alert(1)",
+			err:  ErrSchemeNotAllowed,
+		},
+		{
+			name: "file scheme",
+			url:  "file:///etc/passwd",
+			err:  ErrSchemeNotAllowed,
+		},
+		{
+			name: "data scheme",
+			url:  "data:text/html,<script>alert(1)</script>",
+			err:  ErrSchemeNotAllowed,
+		},
+		{
+			name: "metadata ip",
+			url:  "http://169.254.169.254/latest/meta-data",
+			err:  ErrPrivateNetwork,
+		},
+		{
+			name: "metadata ip as number",
+			url:  "http://2852039166/",
+			err:  ErrPrivateNetwork,
+		},
+		{
+			name: "loopback ipv6",
+			url:  "http://[::1]:8080/",
+			err:  ErrPrivateNetwork,
+		},
+		{
+			name: "localhost",
+			url:  "http://localhost:8081/",
+			err:  ErrPrivateNetwork,
+		},
+		{
+			name: "resolves to private ip",
+			url:  "http://internal.corp/",
+			err:  ErrPrivateNetwork,
+		},
+		{
+			name: "does not exist",
+			url:  "http://missing.example.org/",
+		},
+		{
+			name: "lookup timed out",
+			url:  "http://slow.example.org/",
+			err:  ErrPrivateNetwork,
+		},
+		{
+			name:   "denied wildcard",
+			url:    "https://evil.example.com/",
+			denied: []string{"*.example.com"},
+			err:    ErrDomainNotAllowed,
+		},
+		{
+			name:    "allowed wildcard",
+			url:     "https://docs.ya.ru/",
+			allowed: []string{"*.ya.ru"},
+		},
+		{
+			name:    "not in allowed list",
+			url:     "https://google.com/",
+			allowed: []string{"ya.ru", "*.ya.ru"},
+			err:     ErrDomainNotAllowed,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := New([]string{"http", "https"}, tt.allowed, tt.denied, true, 10*time.Millisecond)
+			p.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+				switch host {
+				case "internal.corp":
+					return []net.IP{net.ParseIP("10.0.0.1")}, nil
+				case "slow.example.org":
+					<-ctx.Done()
+					return nil, &net.DNSError{Err: ctx.Err().Error(), Name: host, IsTimeout: true}
+				case "ya.ru", "docs.ya.ru", "google.com", "evil.example.com":
+					return []net.IP{net.ParseIP("77.88.55.242")}, nil
+				}
+				return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+			}
+
+			err := p.Check(tt.url)
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestCheckNetworks(t *testing.T) {
+	p := New([]string{"http", "https"}, nil, nil, true, time.Second)
+
+	var mu sync.Mutex
+	lookups := make(map[string]int)
+	p.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		mu.Lock()
+		lookups[host]++
+		mu.Unlock()
+
+		// Lookups one after another would take longer than the timeout.
+		time.Sleep(100 * time.Millisecond)
+		if host == "internal.corp" {
+			return []net.IP{net.ParseIP("10.0.0.1")}, nil
+		}
+		return []net.IP{net.ParseIP("77.88.55.242")}, nil
+	}
+
+	urls := []string{"http://127.1/"}
+	for i := 0; i < 20; i++ {
+		urls = append(urls, fmt.Sprintf("https://host%d.example.org/", i), "https://ya.ru/path", "http://internal.corp/")
+	}
+
+	start := time.Now()
+	errs := p.CheckNetworks(urls)
+	require.Less(t, time.Since(start), time.Second, "hosts were not resolved concurrently")
+
+	require.Len(t, errs, len(urls))
+	require.ErrorIs(t, errs[0], ErrPrivateNetwork)
+	for i := 1; i < len(urls); i += 3 {
+		require.NoError(t, errs[i])
+		require.NoError(t, errs[i+1])
+		require.ErrorIs(t, errs[i+2], ErrPrivateNetwork)
+	}
+	require.Equal(t, 1, lookups["ya.ru"])
+	require.Equal(t, 1, lookups["internal.corp"])
+}
@@ -0,0 +1,55 @@
+// Package validation builds the validator of link requests and rows, shared
+// by the handlers and urlctl, so that the url and alias policies are
+// registered once at startup.
+package validation
+
+import (
+	"fmt"
+	"strconv"
+	"url-shortener/internal/config"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/urlpolicy"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Validator knows the tags of urlpolicy and aliaspolicy, and max_bytes.
+type Validator struct {
+	*validator.Validate
+	// URLPolicy is the policy registered on Validate, for the checks made
+	// on many urls at once, see urlpolicy.Policy.CheckNetworks.
+	URLPolicy *urlpolicy.Policy
+}
+
+func New(cfg config.URLPolicy, aliasPolicy *aliaspolicy.Policy) (*Validator, error) {
+	const caller = "lib.validation.New"
+
+	v := &Validator{
+		Validate: validator.New(),
+		URLPolicy: urlpolicy.New(
+			cfg.AllowedSchemes,
+			cfg.AllowedDomains,
+			cfg.DeniedDomains,
+			cfg.BlockPrivateNetworks,
+			cfg.LookupTimeout,
+		),
+	}
+	if err := v.URLPolicy.Register(v.Validate); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err := aliasPolicy.Register(v.Validate); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err := v.RegisterValidation("max_bytes", maxBytes); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return v, nil
+}
+
+// maxBytes limits the length of a string in bytes rather than characters,
+// bcrypt refuses passwords longer than 72 bytes.
+func maxBytes(fl validator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	return err == nil && len(fl.Field().String()) <= limit
+}
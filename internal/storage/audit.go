@@ -0,0 +1,43 @@
+package storage
+
+import "time"
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionTag     = "tag"
+	AuditActionUntag   = "untag"
+	AuditActionImport  = "import"
+	AuditActionPurge   = "purge"
+)
+
+// AuditEvent records a single change of a link. The storage methods making
+// a change take its events and record them in the same transaction, so that
+// no change is made without its event.
+type AuditEvent struct {
+	ID int64
+	// Actor is the SSO email of the admin who made the change.
+	Actor      string
+	Workspace  string
+	Action     string
+	Alias      string
+	OldValue   string
+	NewValue   string
+	RequestID  string
+	RemoteAddr string
+	CreatedAt  time.Time
+}
+
+// AuditFilter narrows the audit events listed, empty fields match anything.
+type AuditFilter struct {
+	// Workspace is always matched, empty is the default workspace.
+	Workspace string
+	Actor     string
+	Action    string
+	Alias     string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
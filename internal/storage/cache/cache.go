@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+	"url-shortener/internal/lib/lru"
+	"url-shortener/internal/storage"
+)
+
+type URLStorage interface {
+	SaveURL(link storage.Link, events ...storage.AuditEvent) error
+	GetURL(alias string) (storage.Link, error)
+	DeleteURL(alias string, events ...storage.AuditEvent) error
+	RestoreURL(alias string, events ...storage.AuditEvent) error
+}
+
+// Cached is a cache in front of URLStorage, either Storage or redis.Storage.
+type Cached interface {
+	URLStorage
+	Invalidate(alias string) error
+}
+
+type entry struct {
+	link  storage.Link
+	found bool
+}
+
+// generations is the number of invalidation counters, aliases share them by
+// hash.
+const generations = 1024
+
+// Storage is a read-through cache in front of URLStorage. Unknown aliases are
+// cached as well, for negativeTTL, so that repeated misses do not hit the db.
+type Storage struct {
+	storage     URLStorage
+	cache       *lru.Cache[string, entry]
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	// mu guards generations. A read of the db is only cached if no
+	// invalidation of its alias happened meanwhile, otherwise a link read
+	// before a change would be cached after it.
+	mu          sync.Mutex
+	generations [generations]uint64
+}
+
+func New(urlStorage URLStorage, size int, ttl time.Duration, negativeTTL time.Duration) *Storage {
+	return &Storage{
+		storage:     urlStorage,
+		cache:       lru.New[string, entry](size),
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+func (s *Storage) SaveURL(link storage.Link, events ...storage.AuditEvent) error {
+	const caller = "storage.cache.SaveURL"
+
+	defer s.Invalidate(link.Alias)
+
+	if err := s.storage.SaveURL(link, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetURL(alias string) (storage.Link, error) {
+	const caller = "storage.cache.GetURL"
+
+	if e, ok := s.cache.Get(alias); ok {
+		if !e.found {
+			return storage.Link{}, fmt.Errorf("%s: failed to get: %w", caller, storage.ErrURLNotFound)
+		}
+		return e.link, nil
+	}
+
+	generation := s.generation(alias)
+
+	link, err := s.storage.GetURL(alias)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		if s.negativeTTL > 0 {
+			s.set(alias, generation, entry{}, s.negativeTTL)
+		}
+		return storage.Link{}, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err != nil {
+		return storage.Link{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	s.set(alias, generation, entry{link: link, found: true}, s.ttl)
+
+	return link, nil
+}
+
+func (s *Storage) DeleteURL(alias string, events ...storage.AuditEvent) error {
+	const caller = "storage.cache.DeleteURL"
+
+	defer s.Invalidate(alias)
+
+	if err := s.storage.DeleteURL(alias, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) RestoreURL(alias string, events ...storage.AuditEvent) error {
+	const caller = "storage.cache.RestoreURL"
+
+	defer s.Invalidate(alias)
+
+	if err := s.storage.RestoreURL(alias, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// Invalidate drops any cached entry for alias, as well as the reads of alias
+// which are not cached yet. It must be called by every code path that
+// changes the destination of an alias, once the change is committed.
+// It never fails, the error is there for Cached.
+func (s *Storage) Invalidate(alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generations[generationOf(alias)]++
+	s.cache.Remove(alias)
+
+	return nil
+}
+
+func (s *Storage) generation(alias string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.generations[generationOf(alias)]
+}
+
+// set caches e unless alias was invalidated since generation was taken.
+func (s *Storage) set(alias string, generation uint64, e entry, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.generations[generationOf(alias)] == generation {
+		s.cache.Set(alias, e, ttl)
+	}
+}
+
+func generationOf(alias string) int {
+	h := fnv.New32a()
+	h.Write([]byte(alias))
+	return int(h.Sum32() % generations)
+}
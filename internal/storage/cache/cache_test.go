@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"testing"
+	"time"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStorage struct {
+	urls map[string]storage.Link
+	gets int
+}
+
+func (f *fakeStorage) SaveURL(link storage.Link, events ...storage.AuditEvent) error {
+	if _, ok := f.urls[link.Alias]; ok {
+		return storage.ErrURLAlreadyExists
+	}
+	f.urls[link.Alias] = link
+	return nil
+}
+
+func (f *fakeStorage) GetURL(alias string) (storage.Link, error) {
+	f.gets++
+	link, ok := f.urls[alias]
+	if !ok {
+		return storage.Link{}, storage.ErrURLNotFound
+	}
+	return link, nil
+}
+
+func (f *fakeStorage) DeleteURL(alias string, events ...storage.AuditEvent) error {
+	if _, ok := f.urls[alias]; !ok {
+		return storage.ErrURLNotFound
+	}
+	delete(f.urls, alias)
+	return nil
+}
+
+func (f *fakeStorage) RestoreURL(alias string, events ...storage.AuditEvent) error {
+	return storage.ErrURLNotFound
+}
+
+func TestCacheReadThrough(t *testing.T) {
+	fake := &fakeStorage{urls: map[string]storage.Link{"test": {Alias: "test", URL: "https://ya.ru"}}}
+	s := New(fake, 10, time.Minute, time.Minute)
+
+	for range 3 {
+		link, err := s.GetURL("test")
+		require.NoError(t, err)
+		require.Equal(t, "https://ya.ru", link.URL)
+	}
+	require.Equal(t, 1, fake.gets)
+}
+
+func TestCacheNegative(t *testing.T) {
+	fake := &fakeStorage{urls: map[string]storage.Link{}}
+	s := New(fake, 10, time.Minute, time.Minute)
+
+	for range 3 {
+		_, err := s.GetURL("missing")
+		require.ErrorIs(t, err, storage.ErrURLNotFound)
+	}
+	require.Equal(t, 1, fake.gets)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "missing", URL: "https://ya.ru"}))
+
+	link, err := s.GetURL("missing")
+	require.NoError(t, err)
+	require.Equal(t, "https://ya.ru", link.URL)
+}
+
+func TestCacheDeleteInvalidates(t *testing.T) {
+	fake := &fakeStorage{urls: map[string]storage.Link{"test": {Alias: "test", URL: "https://ya.ru"}}}
+	s := New(fake, 10, time.Minute, time.Minute)
+
+	_, err := s.GetURL("test")
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteURL("test"))
+
+	_, err = s.GetURL("test")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+// pausedStorage stops the first GetURL after it read the db, until resume
+// is closed.
+type pausedStorage struct {
+	*fakeStorage
+	read   chan struct{}
+	resume chan struct{}
+}
+
+func (p *pausedStorage) GetURL(alias string) (storage.Link, error) {
+	link, err := p.fakeStorage.GetURL(alias)
+	if read := p.read; read != nil {
+		p.read = nil
+		read <- struct{}{}
+		<-p.resume
+	}
+	return link, err
+}
+
+func TestCacheChangeDuringRead(t *testing.T) {
+	testCases := []struct {
+		name   string
+		urls   map[string]storage.Link
+		change func(s *Storage) error
+		err    error
+	}{
+		{
+			name: "delete",
+			urls: map[string]storage.Link{"test": {Alias: "test", URL: "https://ya.ru"}},
+			change: func(s *Storage) error {
+				return s.DeleteURL("test")
+			},
+			err: storage.ErrURLNotFound,
+		},
+		{
+			name: "save",
+			urls: map[string]storage.Link{},
+			change: func(s *Storage) error {
+				return s.SaveURL(storage.Link{Alias: "test", URL: "https://ya.ru"})
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			read, resume := make(chan struct{}), make(chan struct{})
+			paused := &pausedStorage{
+				fakeStorage: &fakeStorage{urls: tt.urls},
+				read:        read,
+				resume:      resume,
+			}
+			s := New(paused, 10, time.Minute, time.Minute)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_, _ = s.GetURL("test")
+			}()
+
+			// The read from before the change ends after it and must not be
+			// cached.
+			<-read
+			require.NoError(t, tt.change(s))
+			close(resume)
+			<-done
+
+			_, err := s.GetURL("test")
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
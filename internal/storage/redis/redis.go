@@ -0,0 +1,235 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"url-shortener/internal/storage"
+	sl "url-shortener/pkg/logger/slog"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type URLStorage interface {
+	SaveURL(link storage.Link, events ...storage.AuditEvent) error
+	GetURL(alias string) (storage.Link, error)
+	DeleteURL(alias string, events ...storage.AuditEvent) error
+	RestoreURL(alias string, events ...storage.AuditEvent) error
+}
+
+const (
+	keyPrefix     = "url-shortener:alias:"
+	versionPrefix = "url-shortener:version:"
+)
+
+// versionTTL keeps the invalidation version of an alias for longer than any
+// read of the db may take.
+const versionTTL = 24 * time.Hour
+
+// setIfVersion caches a link read from the db, unless the version of its
+// alias changed since the read began, i.e. a change was committed and
+// invalidated meanwhile. A missing version reads as "".
+var setIfVersion = redis.NewScript(`
+if (redis.call("GET", KEYS[2]) or "") ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// notFound is cached for unknown aliases. It can never be an encoded link.
+const notFound = ""
+
+// Storage is a read-through cache in front of URLStorage backed by a server
+// speaking the Redis protocol, so that every replica shares the same view of
+// the aliases. Failed reads and writes of the cache are logged and fall
+// through to URLStorage. A change committed to URLStorage is not undone by
+// a failed invalidation, which is logged, the replicas may then serve the
+// old entry until it expires. Password protected links are never cached,
+// their hash stays in the db.
+type Storage struct {
+	storage     URLStorage
+	client      *redis.Client
+	timeout     time.Duration
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+var log *slog.Logger = sl.GetLogger()
+
+func New(
+	urlStorage URLStorage,
+	address string,
+	password string,
+	db int,
+	timeout time.Duration,
+	ttl time.Duration,
+	negativeTTL time.Duration,
+) (*Storage, error) {
+	const caller = "storage.redis.New"
+
+	client := redis.NewClient(&redis.Options{
+		Addr:         address,
+		Password:     password,
+		DB:           db,
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return &Storage{
+		storage:     urlStorage,
+		client:      client,
+		timeout:     timeout,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}, nil
+}
+
+func Close(storage *Storage) error {
+	return storage.client.Close()
+}
+
+func (s *Storage) SaveURL(link storage.Link, events ...storage.AuditEvent) error {
+	const caller = "storage.redis.SaveURL"
+
+	if err := s.storage.SaveURL(link, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	s.invalidateCommitted(caller, link.Alias)
+
+	return nil
+}
+
+func (s *Storage) GetURL(alias string) (storage.Link, error) {
+	const caller = "storage.redis.GetURL"
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	// The version is taken before the db is read, see setIfVersion.
+	version, versionErr := s.client.Get(ctx, versionPrefix+alias).Result()
+	if errors.Is(versionErr, redis.Nil) {
+		version, versionErr = "", nil
+	}
+
+	cached, err := s.client.Get(ctx, keyPrefix+alias).Result()
+	switch {
+	case err == nil && cached == notFound:
+		return storage.Link{}, fmt.Errorf("%s: failed to get: %w", caller, storage.ErrURLNotFound)
+	case err == nil:
+		var link storage.Link
+		if err = json.Unmarshal([]byte(cached), &link); err == nil {
+			return link, nil
+		}
+		log.Warn("failed to decode cached link", slog.String("caller", caller), sl.Err(err))
+	case !errors.Is(err, redis.Nil):
+		log.Warn("failed to read from cache", slog.String("caller", caller), sl.Err(err))
+	}
+
+	link, err := s.storage.GetURL(alias)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		if s.negativeTTL > 0 && versionErr == nil {
+			s.set(ctx, alias, version, notFound, s.negativeTTL)
+		}
+		return storage.Link{}, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err != nil {
+		return storage.Link{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if link.PasswordHash != "" || versionErr != nil {
+		return link, nil
+	}
+
+	encoded, err := json.Marshal(link)
+	if err != nil {
+		log.Warn("failed to encode link", slog.String("caller", caller), sl.Err(err))
+		return link, nil
+	}
+	s.set(ctx, alias, version, string(encoded), s.ttl)
+
+	return link, nil
+}
+
+func (s *Storage) DeleteURL(alias string, events ...storage.AuditEvent) error {
+	const caller = "storage.redis.DeleteURL"
+
+	if err := s.storage.DeleteURL(alias, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	s.invalidateCommitted(caller, alias)
+
+	return nil
+}
+
+func (s *Storage) RestoreURL(alias string, events ...storage.AuditEvent) error {
+	const caller = "storage.redis.RestoreURL"
+
+	if err := s.storage.RestoreURL(alias, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	s.invalidateCommitted(caller, alias)
+
+	return nil
+}
+
+// Invalidate drops the cached entry for alias on every replica, as well as
+// the reads of alias which are not cached yet. It must be called by every
+// code path that changes the destination of an alias, once the change is
+// committed. On error the replicas may serve the old destination until the
+// entry expires.
+func (s *Storage) Invalidate(alias string) error {
+	const caller = "storage.redis.Invalidate"
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, versionPrefix+alias)
+		pipe.Expire(ctx, versionPrefix+alias, versionTTL)
+		pipe.Del(ctx, keyPrefix+alias)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: failed to invalidate cache: %w", caller, err)
+	}
+
+	return nil
+}
+
+// invalidateCommitted invalidates alias after a change was committed to
+// URLStorage. The change stands either way, so a failure is only logged.
+func (s *Storage) invalidateCommitted(caller string, alias string) {
+	if err := s.Invalidate(alias); err != nil {
+		log.Error("changed link stays cached until it expires",
+			slog.String("caller", caller), slog.String("alias", alias), sl.Err(err))
+	}
+}
+
+// set caches value unless alias was invalidated since version was taken.
+func (s *Storage) set(ctx context.Context, alias string, version string, value string, ttl time.Duration) {
+	const caller = "storage.redis.set"
+
+	keys := []string{keyPrefix + alias, versionPrefix + alias}
+	if err := setIfVersion.Run(ctx, s.client, keys, version, value, ttl.Milliseconds()).Err(); err != nil {
+		log.Warn("failed to write to cache", slog.String("caller", caller), sl.Err(err))
+	}
+}
@@ -0,0 +1,214 @@
+package redis
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"url-shortener/internal/storage"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStorage struct {
+	mu   sync.Mutex
+	urls map[string]storage.Link
+	gets int
+}
+
+func (f *fakeStorage) SaveURL(link storage.Link, events ...storage.AuditEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.urls[link.Alias]; ok {
+		return storage.ErrURLAlreadyExists
+	}
+	f.urls[link.Alias] = link
+	return nil
+}
+
+func (f *fakeStorage) GetURL(alias string) (storage.Link, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gets++
+	link, ok := f.urls[alias]
+	if !ok {
+		return storage.Link{}, storage.ErrURLNotFound
+	}
+	return link, nil
+}
+
+func (f *fakeStorage) DeleteURL(alias string, events ...storage.AuditEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.urls[alias]; !ok {
+		return storage.ErrURLNotFound
+	}
+	delete(f.urls, alias)
+	return nil
+}
+
+func (f *fakeStorage) RestoreURL(alias string, events ...storage.AuditEvent) error {
+	return storage.ErrURLNotFound
+}
+
+func newReplica(t *testing.T, addr string, fake *fakeStorage) *Storage {
+	s, err := New(fake, addr, "", 0, time.Second, time.Minute, time.Minute)
+	require.NoError(t, err)
+	t.Cleanup(func() { Close(s) })
+	return s
+}
+
+func TestRedisSharedAcrossReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	fake := &fakeStorage{urls: map[string]storage.Link{"test": {Alias: "test", URL: "https://ya.ru"}}}
+
+	first := newReplica(t, mr.Addr(), fake)
+	second := newReplica(t, mr.Addr(), fake)
+
+	link, err := first.GetURL("test")
+	require.NoError(t, err)
+	require.Equal(t, "https://ya.ru", link.URL)
+
+	link, err = second.GetURL("test")
+	require.NoError(t, err)
+	require.Equal(t, "https://ya.ru", link.URL)
+	require.Equal(t, 1, fake.gets)
+
+	require.NoError(t, first.DeleteURL("test"))
+
+	_, err = second.GetURL("test")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestRedisNegative(t *testing.T) {
+	mr := miniredis.RunT(t)
+	fake := &fakeStorage{urls: map[string]storage.Link{}}
+	s := newReplica(t, mr.Addr(), fake)
+
+	for range 3 {
+		_, err := s.GetURL("missing")
+		require.ErrorIs(t, err, storage.ErrURLNotFound)
+	}
+	require.Equal(t, 1, fake.gets)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "missing", URL: "https://ya.ru"}))
+
+	link, err := s.GetURL("missing")
+	require.NoError(t, err)
+	require.Equal(t, "https://ya.ru", link.URL)
+}
+
+func TestRedisUnavailable(t *testing.T) {
+	mr := miniredis.RunT(t)
+	fake := &fakeStorage{urls: map[string]storage.Link{"test": {Alias: "test", URL: "https://ya.ru"}}}
+	s := newReplica(t, mr.Addr(), fake)
+
+	mr.Close()
+
+	link, err := s.GetURL("test")
+	require.NoError(t, err)
+	require.Equal(t, "https://ya.ru", link.URL)
+}
+
+func TestRedisInvalidateFailed(t *testing.T) {
+	mr := miniredis.RunT(t)
+	fake := &fakeStorage{urls: map[string]storage.Link{"test": {Alias: "test", URL: "https://ya.ru"}}}
+	s := newReplica(t, mr.Addr(), fake)
+
+	mr.Close()
+
+	// The delete is committed, only the invalidation failed.
+	require.NoError(t, s.DeleteURL("test"))
+	require.Empty(t, fake.urls)
+	require.Error(t, s.Invalidate("test"))
+}
+
+func TestRedisSkipsProtected(t *testing.T) {
+	mr := miniredis.RunT(t)
+	fake := &fakeStorage{urls: map[string]storage.Link{"test": {Alias: "test", URL: "https://ya.ru", PasswordHash: "hash"}}}
+	s := newReplica(t, mr.Addr(), fake)
+
+	for range 3 {
+		link, err := s.GetURL("test")
+		require.NoError(t, err)
+		require.Equal(t, "hash", link.PasswordHash)
+	}
+	require.Equal(t, 3, fake.gets)
+	require.False(t, mr.Exists(keyPrefix+"test"))
+}
+
+// pausedStorage stops the first GetURL after it read the db, until resume
+// is closed.
+type pausedStorage struct {
+	*fakeStorage
+	read   chan struct{}
+	resume chan struct{}
+}
+
+func (p *pausedStorage) GetURL(alias string) (storage.Link, error) {
+	link, err := p.fakeStorage.GetURL(alias)
+	if read := p.read; read != nil {
+		p.read = nil
+		read <- struct{}{}
+		<-p.resume
+	}
+	return link, err
+}
+
+func TestRedisChangeDuringRead(t *testing.T) {
+	testCases := []struct {
+		name   string
+		urls   map[string]storage.Link
+		change func(s *Storage) error
+		err    error
+	}{
+		{
+			name: "delete",
+			urls: map[string]storage.Link{"test": {Alias: "test", URL: "https://ya.ru"}},
+			change: func(s *Storage) error {
+				return s.DeleteURL("test")
+			},
+			err: storage.ErrURLNotFound,
+		},
+		{
+			name: "save",
+			urls: map[string]storage.Link{},
+			change: func(s *Storage) error {
+				return s.SaveURL(storage.Link{Alias: "test", URL: "https://ya.ru"})
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mr := miniredis.RunT(t)
+			read, resume := make(chan struct{}), make(chan struct{})
+			fake := &fakeStorage{urls: tt.urls}
+			reader := newReplica(t, mr.Addr(), &fakeStorage{})
+			reader.storage = &pausedStorage{fakeStorage: fake, read: read, resume: resume}
+			writer := newReplica(t, mr.Addr(), fake)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_, _ = reader.GetURL("test")
+			}()
+
+			// The read from before the change on the other replica ends
+			// after it and must not be cached.
+			<-read
+			require.NoError(t, tt.change(writer))
+			close(resume)
+			<-done
+
+			_, err := reader.GetURL("test")
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"url-shortener/internal/storage"
+)
+
+// initAudit creates the audit_events table. Triggers make it append-only.
+func initAudit(db *sql.DB) error {
+	const caller = "storage.sqlite.initAudit"
+
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS audit_events(
+		id INTEGER PRIMARY KEY,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		alias TEXT NOT NULL,
+		old_value TEXT NOT NULL DEFAULT '',
+		new_value TEXT NOT NULL DEFAULT '',
+		request_id TEXT NOT NULL DEFAULT '',
+		remote_addr TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL);
+	CREATE INDEX IF NOT EXISTS idx_audit_events_alias ON audit_events(alias);
+	CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);
+	CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_events is append-only');
+	END;
+	CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_events is append-only');
+	END;
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = addColumn(db, "audit_events", "workspace", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) RecordAudit(event storage.AuditEvent) error {
+	const caller = "storage.sqlite.RecordAudit"
+
+	if err := recordAudit(s.db, event); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// execer is either the db or a transaction.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// recordAudit inserts events, within the transaction of the change they
+// record when db is one.
+func recordAudit(db execer, events ...storage.AuditEvent) error {
+	const caller = "storage.sqlite.recordAudit"
+
+	for _, event := range events {
+		createdAt := event.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+
+		_, err := db.Exec(`
+		INSERT INTO audit_events(
+			actor, workspace, action, alias, old_value, new_value, request_id, remote_addr, created_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			event.Actor, event.Workspace, event.Action, event.Alias, event.OldValue, event.NewValue,
+			event.RequestID, event.RemoteAddr, createdAt.UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	return nil
+}
+
+// ListAudit returns the events matching filter, newest first.
+func (s *Storage) ListAudit(filter storage.AuditFilter) ([]storage.AuditEvent, error) {
+	const caller = "storage.sqlite.ListAudit"
+
+	conditions := []string{"workspace = ?"}
+	args := []any{filter.Workspace}
+	for column, value := range map[string]string{
+		"actor":  filter.Actor,
+		"action": filter.Action,
+		"alias":  filter.Alias,
+	} {
+		if value != "" {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.Until.UTC())
+	}
+
+	query := `
+	SELECT id, actor, workspace, action, alias, old_value, new_value, request_id, remote_addr, created_at
+	FROM audit_events WHERE ` + strings.Join(conditions, " AND ") + " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	defer rows.Close()
+
+	var events []storage.AuditEvent
+	for rows.Next() {
+		var event storage.AuditEvent
+		err = rows.Scan(
+			&event.ID, &event.Actor, &event.Workspace, &event.Action, &event.Alias, &event.OldValue,
+			&event.NewValue, &event.RequestID, &event.RemoteAddr, &event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", caller, err)
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	s := newTestStorage(t)
+
+	start := time.Now().Add(-time.Minute)
+	events := []storage.AuditEvent{
+		{Actor: "admin@example.com", Action: storage.AuditActionCreate, Alias: "a", NewValue: "https://ya.ru"},
+		{Actor: "admin@example.com", Action: storage.AuditActionDelete, Alias: "a", OldValue: "https://ya.ru"},
+		{Actor: "other@example.com", Action: storage.AuditActionCreate, Alias: "b", NewValue: "https://go.dev"},
+	}
+	for _, event := range events {
+		require.NoError(t, s.RecordAudit(event))
+	}
+
+	all, err := s.ListAudit(storage.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	require.Equal(t, "b", all[0].Alias)
+
+	byActor, err := s.ListAudit(storage.AuditFilter{Actor: "admin@example.com", Action: storage.AuditActionDelete})
+	require.NoError(t, err)
+	require.Len(t, byActor, 1)
+	require.Equal(t, "https://ya.ru", byActor[0].OldValue)
+
+	limited, err := s.ListAudit(storage.AuditFilter{Alias: "a", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+
+	inRange, err := s.ListAudit(storage.AuditFilter{Since: start, Until: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, inRange, 3)
+
+	future, err := s.ListAudit(storage.AuditFilter{Since: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	require.Empty(t, future)
+
+	_, err = s.db.Exec("UPDATE audit_events SET actor = 'x'")
+	require.Error(t, err)
+	_, err = s.db.Exec("DELETE FROM audit_events")
+	require.Error(t, err)
+}
+
+func TestAuditWithChange(t *testing.T) {
+	s := newTestStorage(t)
+
+	event := func(action string) storage.AuditEvent {
+		return storage.AuditEvent{Actor: "admin@example.com", Action: action, Alias: "a"}
+	}
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "a", URL: "https://ya.ru"}, event(storage.AuditActionCreate)))
+	require.NoError(t, s.AddTags("a", []string{"ads"}, event(storage.AuditActionTag)))
+	require.NoError(t, s.RemoveTags("a", []string{"ads"}, event(storage.AuditActionUntag)))
+	require.NoError(t, s.DeleteURL("a", event(storage.AuditActionDelete)))
+	require.NoError(t, s.RestoreURL("a", event(storage.AuditActionRestore)))
+
+	// A change that fails records nothing.
+	require.ErrorIs(t, s.RestoreURL("a", event(storage.AuditActionRestore)), storage.ErrURLNotFound)
+
+	events, err := s.ListAudit(storage.AuditFilter{Alias: "a"})
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+	require.Equal(t, storage.AuditActionRestore, events[0].Action)
+	require.Equal(t, "https://ya.ru", events[0].NewValue)
+	require.Equal(t, storage.AuditActionDelete, events[1].Action)
+	require.Equal(t, "https://ya.ru", events[1].OldValue)
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
+	"time"
 	"url-shortener/internal/storage"
 
 	sl "url-shortener/pkg/logger/slog"
@@ -56,41 +58,285 @@ func InitDB(storagePath string) (*Storage, error) {
 
 	log = log.With(slog.String("caller", caller))
 
-	db, err := sql.Open("sqlite3", storagePath)
+	const options = "_foreign_keys=on&_busy_timeout=5000"
+	dsn := storagePath + "?" + options
+	if strings.Contains(storagePath, "?") {
+		dsn = storagePath + "&" + options
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", caller, err)
 	}
 
-	stmt, err := db.Prepare(`
+	_, err = db.Exec(`
 	CREATE TABLE IF NOT EXISTS url(
 		id INTEGER PRIMARY KEY,
-		alias TEXT NOT NULL UNIQUE,
-		url TEXT NOT NULL);
-	CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
+		namespace TEXT NOT NULL DEFAULT '',
+		alias TEXT NOT NULL,
+		url TEXT NOT NULL,
+		UNIQUE(namespace, alias));
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", caller, err)
 	}
 
-	_, err = stmt.Exec()
+	if err = addColumn(db, "url", "redirect_type", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "owner", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "created_at", "TIMESTAMP"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "passthrough", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "password_hash", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "max_clicks", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "remaining_clicks", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "active_from", "TIMESTAMP"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "expires_at", "TIMESTAMP"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "deleted_at", "TIMESTAMP"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "folder", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = addColumn(db, "url", "workspace", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if err = splitNamespaces(db); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS url_rule(
+		id INTEGER PRIMARY KEY,
+		url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		device TEXT NOT NULL DEFAULT '',
+		language TEXT NOT NULL DEFAULT '',
+		country TEXT NOT NULL DEFAULT '',
+		url TEXT NOT NULL);
+	CREATE INDEX IF NOT EXISTS idx_url_rule_url_id ON url_rule(url_id);
+	CREATE TABLE IF NOT EXISTS url_destination(
+		id INTEGER PRIMARY KEY,
+		url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		url TEXT NOT NULL,
+		weight INTEGER NOT NULL,
+		hits INTEGER NOT NULL DEFAULT 0);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_url_destination ON url_destination(url_id, position);
+	CREATE INDEX IF NOT EXISTS idx_url_folder ON url(folder);
+	CREATE INDEX IF NOT EXISTS idx_url_workspace ON url(workspace);
+	CREATE TABLE IF NOT EXISTS tag(
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE);
+	CREATE TABLE IF NOT EXISTS url_tag(
+		url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+		tag_id INTEGER NOT NULL REFERENCES tag(id) ON DELETE CASCADE,
+		PRIMARY KEY (url_id, tag_id));
+	CREATE INDEX IF NOT EXISTS idx_url_tag_tag_id ON url_tag(tag_id);
+	`)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", caller, err)
 	}
 
+	if err = initAudit(db); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
 	log.Info("initiated DB")
 	return &Storage{db: db}, nil
 }
 
-func (s *Storage) SaveURL(urlToSave string, alias string) error {
+// SetCaseInsensitiveAliases makes aliases of a namespace that differ only in
+// case collide on save. Enabling it fails if such aliases are already stored.
+func (s *Storage) SetCaseInsensitiveAliases(enabled bool) error {
+	const caller = "storage.sqlite.SetCaseInsensitiveAliases"
+
+	query := "DROP INDEX IF EXISTS idx_alias_nocase"
+	if enabled {
+		query = "CREATE UNIQUE INDEX IF NOT EXISTS idx_alias_nocase ON url(namespace, alias COLLATE NOCASE)"
+	}
+
+	if _, err := s.db.Exec(query); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// keyColumn selects the key of a link, see storage.Key.
+const keyColumn = "CASE namespace WHEN '' THEN alias ELSE namespace || '/' || alias END"
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
+
+// splitNamespaces moves the namespaces of a url table created by an older
+// version of InitDB to their own column. They were stored in the alias, as
+// {namespace}/{alias}, which was unique across namespaces. SQLite can't drop
+// that constraint, the table is rebuilt.
+func splitNamespaces(db *sql.DB) error {
+	const caller = "storage.sqlite.splitNamespaces"
+
+	var split bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM pragma_table_info('url') WHERE name = 'namespace')").Scan(&split)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	if split {
+		return nil
+	}
+
+	// Dropping the old table must not delete the rules, destinations and tags
+	// of the links. The foreign keys are off on a single connection, the
+	// pragma does nothing inside a transaction.
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+	CREATE TABLE url_namespaced(
+		id INTEGER PRIMARY KEY,
+		namespace TEXT NOT NULL DEFAULT '',
+		alias TEXT NOT NULL,
+		url TEXT NOT NULL,
+		redirect_type INTEGER NOT NULL DEFAULT 0,
+		owner TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP,
+		passthrough INTEGER NOT NULL DEFAULT 0,
+		password_hash TEXT NOT NULL DEFAULT '',
+		max_clicks INTEGER NOT NULL DEFAULT 0,
+		remaining_clicks INTEGER NOT NULL DEFAULT 0,
+		active_from TIMESTAMP,
+		expires_at TIMESTAMP,
+		deleted_at TIMESTAMP,
+		folder TEXT NOT NULL DEFAULT '',
+		workspace TEXT NOT NULL DEFAULT '',
+		UNIQUE(namespace, alias));
+	INSERT INTO url_namespaced(
+		id, namespace, alias, url, redirect_type, owner, created_at, passthrough, password_hash,
+		max_clicks, remaining_clicks, active_from, expires_at, deleted_at, folder, workspace)
+	SELECT
+		id, substr(alias, 1, max(instr(alias, '/') - 1, 0)), substr(alias, instr(alias, '/') + 1),
+		url, redirect_type, owner, created_at, passthrough, password_hash,
+		max_clicks, remaining_clicks, active_from, expires_at, deleted_at, folder, workspace
+	FROM url;
+	DROP TABLE url;
+	ALTER TABLE url_namespaced RENAME TO url;
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	log.Info("moved alias namespaces to their own column")
+	return nil
+}
+
+// addColumn adds a column to a table created by an older version of InitDB.
+func addColumn(db *sql.DB, table string, column string, definition string) error {
+	const caller = "storage.sqlite.addColumn"
+
+	rows, err := db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table))
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// SaveURL saves the link and records events in the same transaction.
+func (s *Storage) SaveURL(link storage.Link, events ...storage.AuditEvent) error {
 	const caller = "storage.sqlite.SaveURL"
 	log = log.With(slog.String("caller", caller))
 
-	stmt, err := s.db.Prepare("INSERT INTO url(url, alias) VALUES(?, ?)")
+	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("%s: %w", caller, err)
 	}
+	defer tx.Rollback()
+
+	if err = saveURL(tx, link); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
 
-	_, err = stmt.Exec(urlToSave, alias)
+	if err = recordAudit(tx, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	log.Info("saved url", slog.String("url", link.URL), slog.String("alias", link.Alias))
+	return nil
+}
+
+// saveURL inserts the link together with its rules, destinations and tags.
+func saveURL(tx *sql.Tx, link storage.Link) error {
+	const caller = "storage.sqlite.saveURL"
+
+	namespace, alias := storage.SplitKey(link.Alias)
+	res, err := tx.Exec(`
+	INSERT INTO url(
+		url, namespace, alias, redirect_type, passthrough, password_hash,
+		max_clicks, remaining_clicks, active_from, expires_at, workspace, folder, owner, created_at)
+	VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		link.URL, namespace, alias, link.RedirectType, link.Passthrough, link.PasswordHash,
+		link.MaxClicks, link.MaxClicks, nullTime(link.ActiveFrom), nullTime(link.ExpiresAt),
+		link.Workspace, link.Folder, link.Owner, time.Now().UTC(),
+	)
 	if err != nil {
 		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
 			return fmt.Errorf("%s: failed to insert: %w", caller, storage.ErrURLAlreadyExists)
@@ -99,41 +345,319 @@ func (s *Storage) SaveURL(urlToSave string, alias string) error {
 		return fmt.Errorf("%s: %w", caller, err)
 	}
 
-	log.Info("saved url", slog.String("url", urlToSave), slog.String("alias", alias))
+	urlID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	for i, rule := range link.Rules {
+		_, err = tx.Exec(
+			"INSERT INTO url_rule(url_id, position, device, language, country, url) VALUES(?, ?, ?, ?, ?, ?)",
+			urlID, i, rule.Device, rule.Language, rule.Country, rule.URL,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	for i, destination := range link.Destinations {
+		_, err = tx.Exec(
+			"INSERT INTO url_destination(url_id, position, url, weight) VALUES(?, ?, ?, ?)",
+			urlID, i, destination.URL, destination.Weight,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	if err = addTags(tx, urlID, link.Tags); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
 	return nil
 }
 
-func (s *Storage) GetURL(alias string) (string, error) {
+func (s *Storage) GetURL(alias string) (storage.Link, error) {
 	const caller = "storage.sqlite.GetURL"
 
-	stmt, err := s.db.Prepare("SELECT url FROM url WHERE alias=?")
+	stmt, err := s.db.Prepare(`
+	SELECT id, ` + keyColumn + `, url, redirect_type, passthrough, password_hash, max_clicks, active_from,
+		expires_at, workspace, folder, owner, created_at
+	FROM url WHERE namespace=? AND alias=? AND deleted_at IS NULL`)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", caller, err)
+		return storage.Link{}, fmt.Errorf("%s: %w", caller, err)
 	}
 
-	var urlFromDB string
-	err = stmt.QueryRow(alias).Scan(&urlFromDB)
+	var urlID int64
+	var link storage.Link
+	var activeFrom, expiresAt, createdAt sql.NullTime
+	namespace, name := storage.SplitKey(alias)
+	err = stmt.QueryRow(namespace, name).Scan(
+		&urlID, &link.Alias, &link.URL, &link.RedirectType, &link.Passthrough, &link.PasswordHash,
+		&link.MaxClicks, &activeFrom, &expiresAt, &link.Workspace, &link.Folder, &link.Owner, &createdAt,
+	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return "", fmt.Errorf("%s: failed to get: %w", caller, storage.ErrURLNotFound)
+			return storage.Link{}, fmt.Errorf("%s: failed to get: %w", caller, storage.ErrURLNotFound)
+		}
+		return storage.Link{}, fmt.Errorf("%s: %w", caller, err)
+	}
+	link.ActiveFrom = activeFrom.Time
+	link.ExpiresAt = expiresAt.Time
+	link.CreatedAt = createdAt.Time
+
+	link.Rules, err = s.rules(urlID)
+	if err != nil {
+		return storage.Link{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	link.Destinations, err = s.destinations(urlID)
+	if err != nil {
+		return storage.Link{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	link.Tags, err = s.tags(urlID)
+	if err != nil {
+		return storage.Link{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return link, nil
+}
+
+func (s *Storage) rules(urlID int64) ([]storage.Rule, error) {
+	const caller = "storage.sqlite.rules"
+
+	rows, err := s.db.Query(
+		"SELECT device, language, country, url FROM url_rule WHERE url_id=? ORDER BY position",
+		urlID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	defer rows.Close()
+
+	var rules []storage.Rule
+	for rows.Next() {
+		var rule storage.Rule
+		if err = rows.Scan(&rule.Device, &rule.Language, &rule.Country, &rule.URL); err != nil {
+			return nil, fmt.Errorf("%s: %w", caller, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return rules, nil
+}
+
+func (s *Storage) destinations(urlID int64) ([]storage.Destination, error) {
+	const caller = "storage.sqlite.destinations"
+
+	rows, err := s.db.Query(
+		"SELECT id, url, weight FROM url_destination WHERE url_id=? ORDER BY position",
+		urlID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	defer rows.Close()
+
+	var destinations []storage.Destination
+	for rows.Next() {
+		var destination storage.Destination
+		if err = rows.Scan(&destination.ID, &destination.URL, &destination.Weight); err != nil {
+			return nil, fmt.Errorf("%s: %w", caller, err)
 		}
+		destinations = append(destinations, destination)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return destinations, nil
+}
+
+// RecordVariant counts a visit of alias served by the destination with id.
+func (s *Storage) RecordVariant(alias string, id int64) error {
+	const caller = "storage.sqlite.RecordVariant"
+
+	namespace, name := storage.SplitKey(alias)
+	res, err := s.db.Exec(`
+	UPDATE url_destination SET hits = hits + 1
+	WHERE id = ? AND url_id = (SELECT id FROM url WHERE namespace = ? AND alias = ? AND deleted_at IS NULL)`,
+		id, namespace, name,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("%s: %w", caller, storage.ErrURLNotFound)
+	}
+
+	return nil
+}
+
+// RedeemClick uses up one click of a link with MaxClicks set. The check and
+// the decrement are a single statement, so concurrent redirects can never
+// redeem more clicks than the link has.
+func (s *Storage) RedeemClick(alias string) (int, error) {
+	const caller = "storage.sqlite.RedeemClick"
+
+	namespace, name := storage.SplitKey(alias)
+	var remaining int
+	err := s.db.QueryRow(`
+	UPDATE url SET remaining_clicks = remaining_clicks - 1
+	WHERE namespace = ? AND alias = ? AND deleted_at IS NULL AND remaining_clicks > 0
+	RETURNING remaining_clicks`,
+		namespace, name,
+	).Scan(&remaining)
+	if err == nil {
+		return remaining, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	var exists bool
+	err = s.db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM url WHERE namespace = ? AND alias = ? AND deleted_at IS NULL)",
+		namespace, name,
+	).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", caller, err)
+	}
+	if !exists {
+		return 0, fmt.Errorf("%s: %w", caller, storage.ErrURLNotFound)
+	}
+
+	return 0, fmt.Errorf("%s: %w", caller, storage.ErrURLExhausted)
+}
+
+// RemainingClicks returns the clicks a link with MaxClicks set has left.
+func (s *Storage) RemainingClicks(alias string) (int, error) {
+	const caller = "storage.sqlite.RemainingClicks"
+
+	namespace, name := storage.SplitKey(alias)
+	var remaining int
+	err := s.db.QueryRow(
+		"SELECT remaining_clicks FROM url WHERE namespace = ? AND alias = ? AND deleted_at IS NULL",
+		namespace, name,
+	).Scan(&remaining)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", caller, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return remaining, nil
+}
+
+// LinkWorkspace returns the workspace of the link, deleted links included.
+func (s *Storage) LinkWorkspace(alias string) (string, error) {
+	const caller = "storage.sqlite.LinkWorkspace"
+
+	var workspace string
+	namespace, name := storage.SplitKey(alias)
+	err := s.db.QueryRow("SELECT workspace FROM url WHERE namespace = ? AND alias = ?", namespace, name).Scan(&workspace)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%s: %w", caller, storage.ErrURLNotFound)
+	}
+	if err != nil {
 		return "", fmt.Errorf("%s: %w", caller, err)
 	}
 
-	return urlFromDB, nil
+	return workspace, nil
 }
 
-func (s *Storage) DeleteURL(alias string) error {
+// UpdateURL changes the settings of a link which is not deleted. The old and
+// the new url of the link are recorded as the values of events.
+func (s *Storage) UpdateURL(alias string, update storage.LinkUpdate, events ...storage.AuditEvent) error {
+	const caller = "storage.sqlite.UpdateURL"
+	log = log.With(slog.String("caller", caller))
+
+	namespace, name := storage.SplitKey(alias)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	defer tx.Rollback()
+
+	var oldURL string
+	err = tx.QueryRow(
+		"SELECT url FROM url WHERE namespace=? AND alias=? AND deleted_at IS NULL",
+		namespace, name,
+	).Scan(&oldURL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: failed to update: %w", caller, storage.ErrURLNotFound)
+		}
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	var expiresAt sql.NullTime
+	if update.ExpiresAt != nil {
+		expiresAt = nullTime(*update.ExpiresAt)
+	}
+
+	var newURL string
+	err = tx.QueryRow(`
+	UPDATE url SET
+		url = COALESCE(?, url),
+		redirect_type = COALESCE(?, redirect_type),
+		passthrough = COALESCE(?, passthrough),
+		folder = COALESCE(?, folder),
+		expires_at = CASE WHEN ? THEN ? ELSE expires_at END
+	WHERE namespace=? AND alias=? AND deleted_at IS NULL
+	RETURNING url`,
+		update.URL, update.RedirectType, update.Passthrough, update.Folder,
+		update.ExpiresAt != nil, expiresAt, namespace, name,
+	).Scan(&newURL)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	for i := range events {
+		events[i].OldValue = oldURL
+		events[i].NewValue = newURL
+	}
+	if err = recordAudit(tx, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	log.Info("updated alias", slog.String("alias", alias))
+	return nil
+}
+
+// DeleteURL moves the link to the trash. Its alias stays taken until the
+// link is restored with RestoreURL or purged with PurgeDeleted. The url of the
+// link is recorded as the old value of events.
+func (s *Storage) DeleteURL(alias string, events ...storage.AuditEvent) error {
 	const caller = "storage.sqlite.DeleteURL"
 	log = log.With(slog.String("caller", caller))
 
-	stmt, err := s.db.Prepare("DELETE FROM url WHERE alias=? RETURNING id")
+	namespace, name := storage.SplitKey(alias)
+	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("%s: %w", caller, err)
 	}
+	defer tx.Rollback()
 
-	var deletedID int
-	err = stmt.QueryRow(alias).Scan(&deletedID)
+	var deletedURL string
+	err = tx.QueryRow(
+		"UPDATE url SET deleted_at=? WHERE namespace=? AND alias=? AND deleted_at IS NULL RETURNING url",
+		time.Now().UTC(), namespace, name,
+	).Scan(&deletedURL)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return fmt.Errorf("%s: failed to delete: %w", caller, storage.ErrURLNotFound)
@@ -141,6 +665,100 @@ func (s *Storage) DeleteURL(alias string) error {
 		return fmt.Errorf("%s: %w", caller, err)
 	}
 
+	for i := range events {
+		events[i].OldValue = deletedURL
+	}
+	if err = recordAudit(tx, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
 	log.Info("deleted alias", slog.String("alias", alias))
 	return nil
 }
+
+// RestoreURL takes the link out of the trash. The url of the link is recorded
+// as the new value of events.
+func (s *Storage) RestoreURL(alias string, events ...storage.AuditEvent) error {
+	const caller = "storage.sqlite.RestoreURL"
+	log = log.With(slog.String("caller", caller))
+
+	namespace, name := storage.SplitKey(alias)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	defer tx.Rollback()
+
+	var restoredURL string
+	err = tx.QueryRow(
+		"UPDATE url SET deleted_at=NULL WHERE namespace=? AND alias=? AND deleted_at IS NOT NULL RETURNING url",
+		namespace, name,
+	).Scan(&restoredURL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: failed to restore: %w", caller, storage.ErrURLNotFound)
+		}
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	for i := range events {
+		events[i].NewValue = restoredURL
+	}
+	if err = recordAudit(tx, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	log.Info("restored alias", slog.String("alias", alias))
+	return nil
+}
+
+// PurgeDeleted removes links deleted before the given time for good. A purge
+// event of actor is recorded for each of them.
+func (s *Storage) PurgeDeleted(before time.Time, actor string) (int64, error) {
+	const caller = "storage.sqlite.PurgeDeleted"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", caller, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		"DELETE FROM url WHERE deleted_at IS NOT NULL AND deleted_at < ? RETURNING "+keyColumn+", url, workspace",
+		before.UTC(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", caller, err)
+	}
+	defer rows.Close()
+
+	var events []storage.AuditEvent
+	for rows.Next() {
+		event := storage.AuditEvent{Actor: actor, Action: storage.AuditActionPurge}
+		if err = rows.Scan(&event.Alias, &event.OldValue, &event.Workspace); err != nil {
+			return 0, fmt.Errorf("%s: %w", caller, err)
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = recordAudit(tx, events...); err != nil {
+		return 0, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return int64(len(events)), nil
+}
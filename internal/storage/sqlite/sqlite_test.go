@@ -0,0 +1,265 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	s, err := InitDB(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { s.db.Close() })
+
+	return s
+}
+
+func TestRedeemClick(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "once", URL: "https://ya.ru", MaxClicks: 1}))
+
+	remaining, err := s.RemainingClicks("once")
+	require.NoError(t, err)
+	require.Equal(t, 1, remaining)
+
+	remaining, err = s.RedeemClick("once")
+	require.NoError(t, err)
+	require.Equal(t, 0, remaining)
+
+	_, err = s.RedeemClick("once")
+	require.ErrorIs(t, err, storage.ErrURLExhausted)
+
+	remaining, err = s.RemainingClicks("once")
+	require.NoError(t, err)
+	require.Equal(t, 0, remaining)
+
+	_, err = s.RedeemClick("missing")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestRedeemClickConcurrent(t *testing.T) {
+	const (
+		maxClicks = 10
+		visitors  = 100
+	)
+
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "limited", URL: "https://ya.ru", MaxClicks: maxClicks}))
+
+	var redeemed, exhausted atomic.Int64
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+
+	for range visitors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, err := s.RedeemClick("limited")
+			switch {
+			case err == nil:
+				redeemed.Add(1)
+			case errors.Is(err, storage.ErrURLExhausted):
+				exhausted.Add(1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	require.EqualValues(t, maxClicks, redeemed.Load())
+	require.EqualValues(t, visitors-maxClicks, exhausted.Load())
+}
+
+func TestSoftDelete(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "test", URL: "https://ya.ru"}))
+	require.NoError(t, s.DeleteURL("test"))
+
+	_, err := s.GetURL("test")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	err = s.DeleteURL("test")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	err = s.SaveURL(storage.Link{Alias: "test", URL: "https://google.com"})
+	require.ErrorIs(t, err, storage.ErrURLAlreadyExists)
+
+	require.NoError(t, s.RestoreURL("test"))
+
+	link, err := s.GetURL("test")
+	require.NoError(t, err)
+	require.Equal(t, "https://ya.ru", link.URL)
+
+	err = s.RestoreURL("test")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestUpdateURL(t *testing.T) {
+	s := newTestStorage(t)
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, s.SaveURL(storage.Link{
+		Alias: "test", URL: "https://ya.ru", RedirectType: 301, Folder: "ads", ExpiresAt: expiresAt,
+	}))
+
+	newURL, passthrough := "https://go.dev", true
+	err := s.UpdateURL("test", storage.LinkUpdate{URL: &newURL, Passthrough: &passthrough},
+		storage.AuditEvent{Actor: "admin@example.com", Action: storage.AuditActionUpdate, Alias: "test"})
+	require.NoError(t, err)
+
+	link, err := s.GetURL("test")
+	require.NoError(t, err)
+	require.Equal(t, "https://go.dev", link.URL)
+	require.True(t, link.Passthrough)
+	require.Equal(t, 301, link.RedirectType)
+	require.Equal(t, "ads", link.Folder)
+	require.True(t, expiresAt.Equal(link.ExpiresAt))
+
+	events, err := s.ListAudit(storage.AuditFilter{Action: storage.AuditActionUpdate})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "https://ya.ru", events[0].OldValue)
+	require.Equal(t, "https://go.dev", events[0].NewValue)
+
+	never := time.Time{}
+	require.NoError(t, s.UpdateURL("test", storage.LinkUpdate{ExpiresAt: &never}))
+
+	link, err = s.GetURL("test")
+	require.NoError(t, err)
+	require.True(t, link.ExpiresAt.IsZero())
+
+	require.NoError(t, s.DeleteURL("test"))
+	err = s.UpdateURL("test", storage.LinkUpdate{URL: &newURL})
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "deleted", URL: "https://ya.ru"}))
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "kept", URL: "https://ya.ru"}))
+	require.NoError(t, s.DeleteURL("deleted"))
+
+	purged, err := s.PurgeDeleted(time.Now().Add(-time.Hour), "purge")
+	require.NoError(t, err)
+	require.EqualValues(t, 0, purged)
+
+	purged, err = s.PurgeDeleted(time.Now().Add(time.Second), "purge")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, purged)
+
+	events, err := s.ListAudit(storage.AuditFilter{Action: storage.AuditActionPurge})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "purge", events[0].Actor)
+	require.Equal(t, "deleted", events[0].Alias)
+	require.Equal(t, "https://ya.ru", events[0].OldValue)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "deleted", URL: "https://google.com"}))
+
+	_, err = s.GetURL("kept")
+	require.NoError(t, err)
+}
+
+func TestNamespaces(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "sale", URL: "https://ya.ru"}))
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "go.brand.com/sale", URL: "https://brand.com", Workspace: "brand"}))
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "go.other.com/sale", URL: "https://other.com", Workspace: "other"}))
+
+	err := s.SaveURL(storage.Link{Alias: "go.brand.com/sale", URL: "https://go.dev", Workspace: "brand"})
+	require.ErrorIs(t, err, storage.ErrURLAlreadyExists)
+
+	link, err := s.GetURL("go.brand.com/sale")
+	require.NoError(t, err)
+	require.Equal(t, "go.brand.com/sale", link.Alias)
+	require.Equal(t, "https://brand.com", link.URL)
+
+	require.NoError(t, s.DeleteURL("go.other.com/sale"))
+	link, err = s.GetURL("sale")
+	require.NoError(t, err)
+	require.Equal(t, "https://ya.ru", link.URL)
+
+	links, err := s.ListURLs(storage.LinkFilter{Workspace: "brand", Namespace: "go.brand.com"})
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	require.Equal(t, "go.brand.com/sale", links[0].Alias)
+}
+
+func TestSplitNamespaces(t *testing.T) {
+	storagePath := filepath.Join(t.TempDir(), "storage.db")
+
+	// The tables of an older version, which stored the namespace in a
+	// globally unique alias.
+	db, err := sql.Open("sqlite3", storagePath+"?_foreign_keys=on")
+	require.NoError(t, err)
+	_, err = db.Exec(`
+	CREATE TABLE url(
+		id INTEGER PRIMARY KEY,
+		alias TEXT NOT NULL UNIQUE,
+		url TEXT NOT NULL);
+	CREATE INDEX idx_alias ON url(alias);
+	CREATE TABLE url_rule(
+		id INTEGER PRIMARY KEY,
+		url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		device TEXT NOT NULL DEFAULT '',
+		language TEXT NOT NULL DEFAULT '',
+		country TEXT NOT NULL DEFAULT '',
+		url TEXT NOT NULL);
+	INSERT INTO url(id, alias, url) VALUES(1, 'sale', 'https://ya.ru'), (2, 'go.brand.com/sale', 'https://brand.com');
+	INSERT INTO url_rule(url_id, position, device, url) VALUES(2, 0, 'ios', 'https://apps.apple.com');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	s, err := InitDB(storagePath)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.db.Close() })
+
+	link, err := s.GetURL("sale")
+	require.NoError(t, err)
+	require.Equal(t, "https://ya.ru", link.URL)
+
+	link, err = s.GetURL("go.brand.com/sale")
+	require.NoError(t, err)
+	require.Equal(t, "go.brand.com/sale", link.Alias)
+	require.Equal(t, []storage.Rule{{Device: "ios", URL: "https://apps.apple.com"}}, link.Rules)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "go.other.com/sale", URL: "https://other.com"}))
+
+	// The foreign keys are back on.
+	require.NoError(t, s.DeleteURL("go.brand.com/sale"))
+	purged, err := s.PurgeDeleted(time.Now().Add(time.Second), "purge")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, purged)
+	var rules int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM url_rule").Scan(&rules))
+	require.Zero(t, rules)
+
+	// The rebuilt table is left alone when the storage is opened again.
+	again, err := InitDB(storagePath)
+	require.NoError(t, err)
+	t.Cleanup(func() { again.db.Close() })
+	link, err = again.GetURL("go.other.com/sale")
+	require.NoError(t, err)
+	require.Equal(t, "https://other.com", link.URL)
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"url-shortener/internal/storage"
+)
+
+// Stats counts the links, tags and audit events of every workspace.
+func (s *Storage) Stats() (storage.Stats, error) {
+	const caller = "storage.sqlite.Stats"
+
+	var stats storage.Stats
+	err := s.db.QueryRow(`
+	SELECT
+		(SELECT count(*) FROM url WHERE deleted_at IS NULL),
+		(SELECT count(*) FROM url WHERE deleted_at IS NOT NULL),
+		(SELECT count(*) FROM tag),
+		(SELECT count(*) FROM audit_events),
+		(SELECT coalesce(sum(hits), 0) FROM url_destination),
+		(SELECT coalesce(sum(max_clicks - remaining_clicks), 0) FROM url WHERE max_clicks > 0)`,
+	).Scan(&stats.Links, &stats.Deleted, &stats.Tags, &stats.AuditEvents, &stats.VariantHits, &stats.RedeemedClicks)
+	if err != nil {
+		return storage.Stats{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return stats, nil
+}
+
+// GroupStats sums the counters of the links matching filter, Limit is
+// ignored.
+func (s *Storage) GroupStats(filter storage.LinkFilter) (storage.GroupStats, error) {
+	const caller = "storage.sqlite.GroupStats"
+
+	where, args := linkConditions(filter)
+	var stats storage.GroupStats
+	err := s.db.QueryRow(`
+	SELECT
+		count(*),
+		coalesce(sum((SELECT sum(hits) FROM url_destination WHERE url_destination.url_id = url.id)), 0),
+		coalesce(sum(CASE WHEN max_clicks > 0 THEN max_clicks - remaining_clicks ELSE 0 END), 0)
+	FROM url WHERE `+where,
+		args...,
+	).Scan(&stats.Links, &stats.VariantHits, &stats.RedeemedClicks)
+	if err != nil {
+		return storage.GroupStats{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return stats, nil
+}
+
+// LinkStats returns the counters of the link, deleted links included.
+func (s *Storage) LinkStats(alias string) (storage.LinkStats, error) {
+	const caller = "storage.sqlite.LinkStats"
+
+	stats := storage.LinkStats{Alias: alias}
+	namespace, name := storage.SplitKey(alias)
+	var urlID int64
+	var deletedAt sql.NullTime
+	err := s.db.QueryRow(
+		"SELECT id, max_clicks, remaining_clicks, deleted_at FROM url WHERE namespace = ? AND alias = ?",
+		namespace, name,
+	).Scan(&urlID, &stats.MaxClicks, &stats.RemainingClicks, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.LinkStats{}, fmt.Errorf("%s: %w", caller, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return storage.LinkStats{}, fmt.Errorf("%s: %w", caller, err)
+	}
+	stats.Deleted = deletedAt.Valid
+
+	rows, err := s.db.Query("SELECT url, weight, hits FROM url_destination WHERE url_id = ? ORDER BY position", urlID)
+	if err != nil {
+		return storage.LinkStats{}, fmt.Errorf("%s: %w", caller, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var destination storage.DestinationStats
+		if err = rows.Scan(&destination.URL, &destination.Weight, &destination.Hits); err != nil {
+			return storage.LinkStats{}, fmt.Errorf("%s: %w", caller, err)
+		}
+		stats.Destinations = append(stats.Destinations, destination)
+	}
+	if err = rows.Err(); err != nil {
+		return storage.LinkStats{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return stats, nil
+}
+
+// Vacuum rebuilds the database file to give the space of purged links back.
+func (s *Storage) Vacuum() error {
+	const caller = "storage.sqlite.Vacuum"
+
+	if _, err := s.db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "once", URL: "https://ya.ru", MaxClicks: 2, Tags: []string{"ads"}}))
+	require.NoError(t, s.SaveURL(storage.Link{
+		Alias: "split",
+		URL:   "https://ya.ru",
+		Destinations: []storage.Destination{
+			{URL: "https://ya.ru/a", Weight: 1},
+			{URL: "https://ya.ru/b", Weight: 3},
+		},
+	}))
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "gone", URL: "https://ya.ru"}))
+	require.NoError(t, s.DeleteURL("gone"))
+
+	_, err := s.RedeemClick("once")
+	require.NoError(t, err)
+	split, err := s.GetURL("split")
+	require.NoError(t, err)
+	require.NoError(t, s.RecordVariant("split", split.Destinations[1].ID))
+	require.NoError(t, s.RecordVariant("split", split.Destinations[1].ID))
+	require.ErrorIs(t, s.RecordVariant("once", split.Destinations[1].ID), storage.ErrURLNotFound)
+
+	stats, err := s.Stats()
+	require.NoError(t, err)
+	require.Equal(t, storage.Stats{Links: 2, Deleted: 1, Tags: 1, VariantHits: 2, RedeemedClicks: 1}, stats)
+
+	groupStats, err := s.GroupStats(storage.LinkFilter{Tag: "ads"})
+	require.NoError(t, err)
+	require.Equal(t, storage.GroupStats{Links: 1, RedeemedClicks: 1}, groupStats)
+
+	groupStats, err = s.GroupStats(storage.LinkFilter{})
+	require.NoError(t, err)
+	require.Equal(t, storage.GroupStats{Links: 2, VariantHits: 2, RedeemedClicks: 1}, groupStats)
+
+	linkStats, err := s.LinkStats("split")
+	require.NoError(t, err)
+	require.Equal(t, []storage.DestinationStats{
+		{URL: "https://ya.ru/a", Weight: 1},
+		{URL: "https://ya.ru/b", Weight: 3, Hits: 2},
+	}, linkStats.Destinations)
+
+	linkStats, err = s.LinkStats("once")
+	require.NoError(t, err)
+	require.Equal(t, 1, linkStats.RemainingClicks)
+
+	linkStats, err = s.LinkStats("gone")
+	require.NoError(t, err)
+	require.True(t, linkStats.Deleted)
+
+	_, err = s.LinkStats("missing")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	_, err = s.PurgeDeleted(time.Now().Add(time.Second), "purge")
+	require.NoError(t, err)
+	require.NoError(t, s.Vacuum())
+}
@@ -0,0 +1,227 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"url-shortener/internal/storage"
+)
+
+// addTags attaches tags to the link, tags it already has are skipped.
+func addTags(tx *sql.Tx, urlID int64, tags []string) error {
+	const caller = "storage.sqlite.addTags"
+
+	for _, tag := range tags {
+		_, err := tx.Exec("INSERT INTO tag(name) VALUES(?) ON CONFLICT(name) DO NOTHING", tag)
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+
+		_, err = tx.Exec(`
+		INSERT INTO url_tag(url_id, tag_id) SELECT ?, id FROM tag WHERE name = ?
+		ON CONFLICT DO NOTHING`,
+			urlID, tag,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) tags(urlID int64) ([]string, error) {
+	const caller = "storage.sqlite.tags"
+
+	rows, err := s.db.Query(`
+	SELECT tag.name FROM url_tag JOIN tag ON tag.id = url_tag.tag_id
+	WHERE url_tag.url_id = ? ORDER BY tag.name`,
+		urlID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err = rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("%s: %w", caller, err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return tags, nil
+}
+
+func (s *Storage) urlID(tx *sql.Tx, alias string) (int64, error) {
+	const caller = "storage.sqlite.urlID"
+
+	namespace, name := storage.SplitKey(alias)
+	var urlID int64
+	err := tx.QueryRow(
+		"SELECT id FROM url WHERE namespace = ? AND alias = ? AND deleted_at IS NULL",
+		namespace, name,
+	).Scan(&urlID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", caller, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return urlID, nil
+}
+
+// AddTags attaches tags to the link and records events in the same transaction.
+func (s *Storage) AddTags(alias string, tags []string, events ...storage.AuditEvent) error {
+	const caller = "storage.sqlite.AddTags"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	defer tx.Rollback()
+
+	urlID, err := s.urlID(tx, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = addTags(tx, urlID, tags); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = recordAudit(tx, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// RemoveTags detaches tags from the link and records events in the same
+// transaction. Nothing is removed if the link lacks one of the tags.
+func (s *Storage) RemoveTags(alias string, tags []string, events ...storage.AuditEvent) error {
+	const caller = "storage.sqlite.RemoveTags"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+	defer tx.Rollback()
+
+	urlID, err := s.urlID(tx, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	for _, tag := range tags {
+		res, err := tx.Exec(
+			"DELETE FROM url_tag WHERE url_id = ? AND tag_id = (SELECT id FROM tag WHERE name = ?)",
+			urlID, tag,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+
+		removed, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+		if removed == 0 {
+			return fmt.Errorf("%s: %s: %w", caller, tag, storage.ErrTagNotFound)
+		}
+	}
+
+	if err = recordAudit(tx, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// linkConditions returns the WHERE clause of the links matching filter.
+func linkConditions(filter storage.LinkFilter) (string, []any) {
+	conditions := []string{"deleted_at IS NULL", "workspace = ?"}
+	args := []any{filter.Workspace}
+	if filter.Namespace != "" || filter.OwnNamespace {
+		conditions = append(conditions, "namespace = ?")
+		args = append(args, filter.Namespace)
+	}
+	if filter.Folder != "" {
+		conditions = append(conditions, "folder = ?")
+		args = append(args, filter.Folder)
+	}
+	if filter.Tag != "" {
+		conditions = append(conditions, `id IN (
+			SELECT url_tag.url_id FROM url_tag JOIN tag ON tag.id = url_tag.tag_id WHERE tag.name = ?)`)
+		args = append(args, filter.Tag)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// ListURLs returns the links matching filter, newest first. Rules and
+// destinations are not loaded.
+func (s *Storage) ListURLs(filter storage.LinkFilter) ([]storage.Link, error) {
+	const caller = "storage.sqlite.ListURLs"
+
+	where, args := linkConditions(filter)
+	query := `
+	SELECT id, ` + keyColumn + `, url, redirect_type, passthrough, max_clicks, active_from, workspace, folder, owner, created_at
+	FROM url WHERE ` + where + " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	defer rows.Close()
+
+	var urlIDs []int64
+	var links []storage.Link
+	for rows.Next() {
+		var urlID int64
+		var link storage.Link
+		var activeFrom, createdAt sql.NullTime
+		err = rows.Scan(
+			&urlID, &link.Alias, &link.URL, &link.RedirectType, &link.Passthrough,
+			&link.MaxClicks, &activeFrom, &link.Workspace, &link.Folder, &link.Owner, &createdAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", caller, err)
+		}
+		link.ActiveFrom = activeFrom.Time
+		link.CreatedAt = createdAt.Time
+		urlIDs = append(urlIDs, urlID)
+		links = append(links, link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	rows.Close()
+
+	for i, urlID := range urlIDs {
+		links[i].Tags, err = s.tags(urlID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	return links, nil
+}
@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"testing"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "a", URL: "https://ya.ru", Folder: "search", Tags: []string{"q3", "ads", "q3"}}))
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "b", URL: "https://go.dev", Tags: []string{"docs"}}))
+
+	link, err := s.GetURL("a")
+	require.NoError(t, err)
+	require.Equal(t, "search", link.Folder)
+	require.Equal(t, []string{"ads", "q3"}, link.Tags)
+
+	require.NoError(t, s.AddTags("b", []string{"ads"}))
+	require.ErrorIs(t, s.RemoveTags("a", []string{"ads", "missing"}), storage.ErrTagNotFound)
+	require.NoError(t, s.RemoveTags("a", []string{"ads"}))
+	require.ErrorIs(t, s.RemoveTags("a", []string{"ads"}), storage.ErrTagNotFound)
+	require.ErrorIs(t, s.AddTags("missing", []string{"ads"}), storage.ErrURLNotFound)
+
+	byTag, err := s.ListURLs(storage.LinkFilter{Tag: "ads"})
+	require.NoError(t, err)
+	require.Len(t, byTag, 1)
+	require.Equal(t, "b", byTag[0].Alias)
+	require.Equal(t, []string{"ads", "docs"}, byTag[0].Tags)
+
+	byFolder, err := s.ListURLs(storage.LinkFilter{Folder: "search"})
+	require.NoError(t, err)
+	require.Len(t, byFolder, 1)
+	require.Equal(t, "a", byFolder[0].Alias)
+
+	require.NoError(t, s.DeleteURL("b"))
+	all, err := s.ListURLs(storage.LinkFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+}
+
+func TestWorkspaces(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "home", URL: "https://ya.ru"}))
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "eng/home", URL: "https://go.dev", Workspace: "eng"}))
+
+	workspace, err := s.LinkWorkspace("eng/home")
+	require.NoError(t, err)
+	require.Equal(t, "eng", workspace)
+
+	require.NoError(t, s.DeleteURL("eng/home"))
+	workspace, err = s.LinkWorkspace("eng/home")
+	require.NoError(t, err)
+	require.Equal(t, "eng", workspace)
+	require.NoError(t, s.RestoreURL("eng/home"))
+
+	_, err = s.LinkWorkspace("missing")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	links, err := s.ListURLs(storage.LinkFilter{Workspace: "eng"})
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	require.Equal(t, "eng/home", links[0].Alias)
+
+	links, err = s.ListURLs(storage.LinkFilter{})
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	require.Equal(t, "home", links[0].Alias)
+}
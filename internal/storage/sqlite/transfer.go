@@ -0,0 +1,176 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"url-shortener/internal/storage"
+)
+
+type Import struct {
+	tx *sql.Tx
+}
+
+// BeginImport starts an import, see storage.Import.
+func (s *Storage) BeginImport() (storage.Import, error) {
+	const caller = "storage.sqlite.BeginImport"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return &Import{tx: tx}, nil
+}
+
+func (i *Import) LinkWorkspace(alias string) (string, error) {
+	const caller = "storage.sqlite.Import.LinkWorkspace"
+
+	namespace, name := storage.SplitKey(alias)
+	var workspace string
+	err := i.tx.QueryRow("SELECT workspace FROM url WHERE namespace = ? AND alias = ?", namespace, name).Scan(&workspace)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%s: %w", caller, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return workspace, nil
+}
+
+func (i *Import) SaveURL(link storage.Link, overwrite bool, events ...storage.AuditEvent) error {
+	const caller = "storage.sqlite.Import.SaveURL"
+
+	if overwrite {
+		namespace, alias := storage.SplitKey(link.Alias)
+		var replacedURL string
+		err := i.tx.QueryRow(
+			"DELETE FROM url WHERE namespace = ? AND alias = ? RETURNING url",
+			namespace, alias,
+		).Scan(&replacedURL)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+		for j := range events {
+			events[j].OldValue = replacedURL
+		}
+	}
+
+	if err := saveURL(i.tx, link); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	if err := recordAudit(i.tx, events...); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+func (i *Import) Commit() error {
+	const caller = "storage.sqlite.Import.Commit"
+
+	if err := i.tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// Rollback discards the import, it does nothing after Commit.
+func (i *Import) Rollback() error {
+	const caller = "storage.sqlite.Import.Rollback"
+
+	if err := i.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// exportPageSize is the number of links ExportURLs reads at once.
+const exportPageSize = 500
+
+// ExportURLs calls fn for every link matching filter, oldest first. The links
+// are read a page at a time and fn is only called once the read of a page is
+// done, so a slow fn does not keep writers waiting. Only the url, expiry,
+// folder and tags of the links are loaded.
+func (s *Storage) ExportURLs(filter storage.LinkFilter, fn func(link storage.Link) error) error {
+	const caller = "storage.sqlite.ExportURLs"
+
+	var afterID int64
+	for exported := 0; filter.Limit <= 0 || exported < filter.Limit; {
+		pageSize := exportPageSize
+		if filter.Limit > 0 {
+			pageSize = min(pageSize, filter.Limit-exported)
+		}
+
+		links, lastID, err := s.exportPage(filter, afterID, pageSize)
+		if err != nil {
+			return fmt.Errorf("%s: %w", caller, err)
+		}
+
+		for _, link := range links {
+			if err = fn(link); err != nil {
+				return fmt.Errorf("%s: %w", caller, err)
+			}
+		}
+
+		if len(links) < pageSize {
+			break
+		}
+		exported += len(links)
+		afterID = lastID
+	}
+
+	return nil
+}
+
+// exportPage reads up to limit links of ExportURLs with an id above afterID,
+// it returns the id of the last one.
+func (s *Storage) exportPage(filter storage.LinkFilter, afterID int64, limit int) ([]storage.Link, int64, error) {
+	const caller = "storage.sqlite.exportPage"
+
+	where, args := linkConditions(filter)
+	query := `
+	SELECT id, ` + keyColumn + `, url, expires_at, folder, workspace, owner, created_at, (
+		SELECT group_concat(tag.name, char(10)) FROM url_tag JOIN tag ON tag.id = url_tag.tag_id
+		WHERE url_tag.url_id = url.id)
+	FROM url WHERE ` + where + " AND id > ? ORDER BY id LIMIT ?"
+	args = append(args, afterID, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", caller, err)
+	}
+	defer rows.Close()
+
+	var links []storage.Link
+	var lastID int64
+	for rows.Next() {
+		var link storage.Link
+		var expiresAt, createdAt sql.NullTime
+		var tags sql.NullString
+		err = rows.Scan(
+			&lastID, &link.Alias, &link.URL, &expiresAt, &link.Folder, &link.Workspace, &link.Owner, &createdAt, &tags,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", caller, err)
+		}
+		link.ExpiresAt = expiresAt.Time
+		link.CreatedAt = createdAt.Time
+		if tags.String != "" {
+			link.Tags = strings.Split(tags.String, "\n")
+			sort.Strings(link.Tags)
+		}
+		links = append(links, link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return links, lastID, nil
+}
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "old", URL: "https://ya.ru", Tags: []string{"legacy"}}))
+
+	imp, err := s.BeginImport()
+	require.NoError(t, err)
+	require.NoError(t, imp.SaveURL(storage.Link{Alias: "new", URL: "https://go.dev", Tags: []string{"docs"}}, false))
+	require.ErrorIs(t, imp.SaveURL(storage.Link{Alias: "old", URL: "https://go.dev"}, false), storage.ErrURLAlreadyExists)
+	require.NoError(t, imp.SaveURL(storage.Link{Alias: "old", URL: "https://go.dev/doc"}, true))
+
+	workspace, err := imp.LinkWorkspace("new")
+	require.NoError(t, err)
+	require.Empty(t, workspace)
+
+	require.NoError(t, imp.Rollback())
+	_, err = s.GetURL("new")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	imp, err = s.BeginImport()
+	require.NoError(t, err)
+	require.NoError(t, imp.SaveURL(storage.Link{Alias: "new", URL: "https://go.dev", Tags: []string{"docs"}}, false))
+	require.NoError(t, imp.SaveURL(
+		storage.Link{Alias: "old", URL: "https://go.dev/doc"},
+		true,
+		storage.AuditEvent{Actor: "urlctl", Action: storage.AuditActionImport, Alias: "old", NewValue: "https://go.dev/doc"},
+	))
+	require.NoError(t, imp.Commit())
+	require.NoError(t, imp.Rollback())
+
+	link, err := s.GetURL("old")
+	require.NoError(t, err)
+	require.Equal(t, "https://go.dev/doc", link.URL)
+	require.Empty(t, link.Tags)
+
+	// The url an overwrite replaces is kept in the audit log.
+	events, err := s.ListAudit(storage.AuditFilter{Alias: "old"})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "https://ya.ru", events[0].OldValue)
+	require.Equal(t, "https://go.dev/doc", events[0].NewValue)
+}
+
+func TestExportURLs(t *testing.T) {
+	s := newTestStorage(t)
+
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, s.SaveURL(storage.Link{
+		Alias: "a", URL: "https://ya.ru", Tags: []string{"q3", "ads"}, Folder: "f", ExpiresAt: expiresAt,
+	}))
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "b", URL: "https://go.dev"}))
+	require.NoError(t, s.SaveURL(storage.Link{Alias: "eng/c", URL: "https://go.dev/doc"}))
+
+	var links []storage.Link
+	collect := func(link storage.Link) error {
+		links = append(links, link)
+		return nil
+	}
+
+	require.NoError(t, s.ExportURLs(storage.LinkFilter{OwnNamespace: true}, collect))
+	require.Len(t, links, 2)
+	require.Equal(t, "a", links[0].Alias)
+	require.Equal(t, []string{"ads", "q3"}, links[0].Tags)
+	require.Equal(t, "f", links[0].Folder)
+	require.True(t, expiresAt.Equal(links[0].ExpiresAt))
+	require.Empty(t, links[1].Tags)
+	require.True(t, links[1].ExpiresAt.IsZero())
+
+	links = nil
+	require.NoError(t, s.ExportURLs(storage.LinkFilter{OwnNamespace: true, Limit: 1}, collect))
+	require.Len(t, links, 1)
+	require.Equal(t, "a", links[0].Alias)
+
+	// No read is left open while fn runs, writes do not wait for the export.
+	links = nil
+	require.NoError(t, s.ExportURLs(storage.LinkFilter{OwnNamespace: true}, func(link storage.Link) error {
+		links = append(links, link)
+		return s.DeleteURL(link.Alias)
+	}))
+	require.Len(t, links, 2)
+
+	links = nil
+	require.NoError(t, s.ExportURLs(storage.LinkFilter{Namespace: "eng", OwnNamespace: true}, collect))
+	require.Len(t, links, 1)
+	require.Equal(t, "eng/c", links[0].Alias)
+}
+
+func TestExportURLsPages(t *testing.T) {
+	s := newTestStorage(t)
+
+	imp, err := s.BeginImport()
+	require.NoError(t, err)
+	for i := 0; i < exportPageSize+1; i++ {
+		require.NoError(t, imp.SaveURL(storage.Link{Alias: fmt.Sprintf("l%d", i), URL: "https://ya.ru"}, false))
+	}
+	require.NoError(t, imp.Commit())
+
+	var aliases []string
+	require.NoError(t, s.ExportURLs(storage.LinkFilter{}, func(link storage.Link) error {
+		aliases = append(aliases, link.Alias)
+		return nil
+	}))
+	require.Len(t, aliases, exportPageSize+1)
+	require.Equal(t, "l0", aliases[0])
+	require.Equal(t, fmt.Sprintf("l%d", exportPageSize), aliases[exportPageSize])
+
+	aliases = nil
+	require.NoError(t, s.ExportURLs(storage.LinkFilter{Limit: exportPageSize}, func(link storage.Link) error {
+		aliases = append(aliases, link.Alias)
+		return nil
+	}))
+	require.Len(t, aliases, exportPageSize)
+}
@@ -0,0 +1,37 @@
+package storage
+
+// Stats counts what is stored, for all workspaces.
+type Stats struct {
+	Links       int64
+	Deleted     int64
+	Tags        int64
+	AuditEvents int64
+	// VariantHits are the visits served by the destinations of split links.
+	VariantHits int64
+	// RedeemedClicks are the clicks used up by links with MaxClicks set.
+	RedeemedClicks int64
+}
+
+// GroupStats sums the counters of the links matching a LinkFilter, deleted
+// links are left out.
+type GroupStats struct {
+	Links          int64
+	VariantHits    int64
+	RedeemedClicks int64
+}
+
+// LinkStats are the counters kept for a single link. Only split links and
+// links with MaxClicks count their visits.
+type LinkStats struct {
+	Alias           string
+	Deleted         bool
+	MaxClicks       int
+	RemainingClicks int
+	Destinations    []DestinationStats
+}
+
+type DestinationStats struct {
+	URL    string
+	Weight int
+	Hits   int64
+}
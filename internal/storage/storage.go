@@ -1,8 +1,126 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
 var (
 	ErrURLNotFound      = errors.New("url not found")
 	ErrURLAlreadyExists = errors.New("url exists")
+	ErrURLExhausted     = errors.New("url has no clicks left")
+	ErrTagNotFound      = errors.New("tag not found")
 )
+
+// Link is a single alias together with everything stored for it.
+type Link struct {
+	// Alias is the key of the link, prefixed with its namespace, e.g.
+	// eng/docs or go.brand.com/sale, if the link has one, see Key.
+	Alias string
+	URL   string
+	// RedirectType is the HTTP status used for the redirect,
+	// 0 means the default from the config.
+	RedirectType int
+	// Passthrough appends the extra path and query of the request
+	// to the destination.
+	Passthrough bool
+	// Rules are checked in order before falling back to URL.
+	Rules []Rule
+	// Destinations split the visitors of a link between weighted urls,
+	// URL is only used when there are none.
+	Destinations []Destination
+	// ActiveFrom is the time the link starts redirecting, zero means at once.
+	ActiveFrom time.Time
+	// ExpiresAt is the time the link stops redirecting, zero means never.
+	ExpiresAt time.Time
+	// MaxClicks is the number of redirects the link serves, 0 means unlimited.
+	MaxClicks int
+	// PasswordHash is the bcrypt hash of the password protecting the link,
+	// empty if there is none.
+	PasswordHash string
+	// Workspace is the name of the workspace the link belongs to,
+	// empty for the default one.
+	Workspace string
+	// Folder groups the link with others of the same project, empty if none.
+	Folder string
+	Tags   []string
+	// Owner is the email of the admin who created the link.
+	Owner     string
+	CreatedAt time.Time
+}
+
+// Key returns the key alias is stored under in namespace, a workspace prefix
+// or a custom domain. The links of the default namespace, which is empty, are
+// stored under their alias.
+func Key(namespace string, alias string) string {
+	if namespace == "" {
+		return alias
+	}
+	return namespace + "/" + alias
+}
+
+// SplitKey returns the namespace and the alias of key, the reverse of Key.
+// Namespaces never contain a slash.
+func SplitKey(key string) (namespace string, alias string) {
+	if namespace, alias, ok := strings.Cut(key, "/"); ok {
+		return namespace, alias
+	}
+	return "", key
+}
+
+// LinkUpdate changes the settings of a link, nil fields are kept.
+type LinkUpdate struct {
+	URL          *string
+	RedirectType *int
+	Passthrough  *bool
+	Folder       *string
+	// ExpiresAt set to the zero time makes the link never expire.
+	ExpiresAt *time.Time
+}
+
+// LinkFilter narrows the links listed, empty fields match anything.
+type LinkFilter struct {
+	// Workspace is always matched, empty is the default workspace.
+	Workspace string
+	// Namespace is the namespace of the links matched, see Key. With
+	// OwnNamespace an empty Namespace only matches links without one.
+	Namespace    string
+	OwnNamespace bool
+	Tag          string
+	Folder       string
+	Limit        int
+}
+
+// Rule redirects to URL when every non-empty condition matches the request.
+type Rule struct {
+	// Device is one of ios, android or desktop.
+	Device string
+	// Language is matched against the Accept-Language header, e.g. de or pt-BR.
+	Language string
+	// Country is an ISO 3166-1 alpha-2 code looked up from the client address.
+	Country string
+	URL     string
+}
+
+// Destination is a variant of a split link.
+type Destination struct {
+	// ID is set by the storage. Unlike the position of the destination it
+	// does not change when the other destinations do.
+	ID     int64
+	URL    string
+	Weight int
+}
+
+// Import saves the links of one import atomically, nothing is saved
+// until Commit.
+type Import interface {
+	// LinkWorkspace returns the workspace of the link, deleted links included.
+	LinkWorkspace(alias string) (string, error)
+	// SaveURL saves the link, replacing a link with the same alias if
+	// overwrite is set, and records events. The url of the replaced link is
+	// recorded as their old value.
+	SaveURL(link Link, overwrite bool, events ...AuditEvent) error
+	Commit() error
+	Rollback() error
+}
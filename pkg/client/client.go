@@ -0,0 +1,319 @@
+// Package client is a Go client of the url-shortener API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetries    = 3
+	defaultBackoff    = 100 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+	workspaceHeader   = "X-Workspace"
+	apiKeyHeader      = "X-API-Key"
+	passwordHeader    = "X-Link-Password"
+	maxErrorBodyBytes = 64 * 1024
+)
+
+// Client calls the admin endpoints under /url and resolves short links. It
+// is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	// resolver is httpClient without following redirects.
+	resolver  *http.Client
+	auth      func(r *http.Request)
+	workspace string
+	domain    string
+	retries   int
+	backoff   time.Duration
+}
+
+type Option func(c *Client)
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithBasicAuth authenticates as the SSO admin email, which is what the
+// server checks.
+func WithBasicAuth(email string, password string) Option {
+	return func(c *Client) {
+		c.auth = func(r *http.Request) {
+			r.SetBasicAuth(email, password)
+		}
+	}
+}
+
+// WithBearerToken sends token in the Authorization header, for a server
+// behind a proxy which authenticates with tokens.
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.auth = func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+// WithAPIKey sends key in the X-API-Key header, for a server behind a
+// proxy which authenticates with API keys.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.auth = func(r *http.Request) {
+			r.Header.Set(apiKeyHeader, key)
+		}
+	}
+}
+
+// WithWorkspace picks the workspace of an admin who is a member of several.
+func WithWorkspace(name string) Option {
+	return func(c *Client) {
+		c.workspace = name
+	}
+}
+
+// WithDomain works on the links of a custom domain of the workspace.
+func WithDomain(domain string) Option {
+	return func(c *Client) {
+		c.domain = domain
+	}
+}
+
+// WithRetries sets how often a failed GET or DELETE is retried, waiting
+// backoff, twice as long each time with some jitter. The default is 3
+// retries starting at 100ms, 0 disables retries. A retried DELETE answered
+// with not found succeeds, the attempt before it may have deleted the link.
+// Requests creating or updating links and Resolve are never retried, a retry
+// could create a link twice or use up a second click.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// New returns a client of the shortener served at baseURL, e.g.
+// https://sho.rt.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	const caller = "client.New"
+
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("%s: invalid base url %q", caller, baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retries < 0 {
+		c.retries = 0
+	}
+
+	resolver := *c.httpClient
+	resolver.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	c.resolver = &resolver
+
+	return c, nil
+}
+
+// request is a call of an endpoint.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	header      http.Header
+	// admin requests are authenticated and scoped to the workspace.
+	admin bool
+	// once is set for GET requests which are not idempotent.
+	once bool
+}
+
+// do sends req and decodes the JSON response into out, which must embed
+// apiResponse. Both are done even if the response is an error, some errors
+// come with a body, e.g. the rows of a failed import.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	res, retried, err := c.send(ctx, c.httpClient, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	err = decode(res, out)
+	// The delete of a retried request which is not found was most likely
+	// done by an attempt whose response was lost.
+	if retried && req.method == http.MethodDelete && errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// send sends req, retrying idempotent requests on network errors and on the
+// gateway errors of a server which is restarting. It reports whether the
+// response is the one of a retry.
+func (c *Client) send(ctx context.Context, httpClient *http.Client, req request) (*http.Response, bool, error) {
+	const caller = "client.send"
+
+	retries := c.retries
+	if req.once || req.method != http.MethodGet && req.method != http.MethodDelete {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		r, err := c.newRequest(ctx, req)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", caller, err)
+		}
+
+		res, err := httpClient.Do(r)
+		if attempt >= retries || err == nil && !retryable(res.StatusCode) {
+			if err != nil {
+				return nil, false, fmt.Errorf("%s: %w", caller, err)
+			}
+			return res, attempt > 0, nil
+		}
+		if err == nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxErrorBodyBytes))
+			res.Body.Close()
+		}
+
+		if err = c.wait(ctx, attempt); err != nil {
+			return nil, false, fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, req request) (*http.Request, error) {
+	u, err := url.Parse(c.baseURL.String() + escapePath(req.path))
+	if err != nil {
+		return nil, err
+	}
+
+	query := req.query
+	if req.admin && c.domain != "" {
+		if query == nil {
+			query = url.Values{}
+		}
+		query.Set("domain", c.domain)
+	}
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	r, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range req.header {
+		r.Header[key] = values
+	}
+	if req.contentType != "" {
+		r.Header.Set("Content-Type", req.contentType)
+	}
+	r.Header.Set("Accept", "application/json")
+	if req.admin {
+		if c.auth != nil {
+			c.auth(r)
+		}
+		if c.workspace != "" {
+			r.Header.Set(workspaceHeader, c.workspace)
+		}
+	}
+
+	return r, nil
+}
+
+// wait sleeps before the retry after attempt, until ctx is done.
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	backoff := c.backoff << attempt
+	if backoff > maxBackoff || backoff <= 0 {
+		backoff = maxBackoff
+	}
+	// Jitter keeps clients failing together from retrying together.
+	backoff = backoff/2 + rand.N(backoff/2+1)
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// escapePath escapes the segments of path, keeping its slashes.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// decode reads the JSON body of res into out and returns the error of the
+// response, if any.
+func decode(res *http.Response, out any) error {
+	const caller = "client.decode"
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	var status apiResponse
+	if len(bytes.TrimSpace(body)) > 0 && json.Unmarshal(body, &status) == nil && status.Status != "" {
+		if out != nil {
+			if err = json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("%s: %w", caller, err)
+			}
+		}
+		if status.Status != statusOK {
+			return newError(res.StatusCode, status.Error)
+		}
+		return nil
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return newError(res.StatusCode, "")
+	}
+	return fmt.Errorf("%s: unexpected response with status %d", caller, res.StatusCode)
+}
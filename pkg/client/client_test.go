@@ -0,0 +1,283 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := New(server.URL, append([]Option{WithRetries(2, time.Millisecond)}, opts...)...)
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestCreate(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/url", r.URL.Path)
+		require.Equal(t, "eng", r.Header.Get("X-Workspace"))
+		require.Equal(t, "go.brand.com", r.URL.Query().Get("domain"))
+
+		email, password, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "admin@example.com", email)
+		require.Equal(t, "secret", password)
+
+		var req CreateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "https://go.dev", req.URL)
+		require.Equal(t, []string{"docs"}, req.Tags)
+
+		_, _ = io.WriteString(w, `{"status":"OK","alias":"go","short_url":"https://go.brand.com/go"}`)
+	},
+		WithBasicAuth("admin@example.com", "secret"),
+		WithWorkspace("eng"),
+		WithDomain("go.brand.com"),
+	)
+
+	created, err := c.Create(context.Background(), CreateRequest{URL: "https://go.dev", Alias: "go", Tags: []string{"docs"}})
+	require.NoError(t, err)
+	require.Equal(t, Created{Alias: "go", ShortURL: "https://go.brand.com/go"}, created)
+}
+
+func TestErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		status  int
+		body    string
+		err     error
+		message string
+	}{
+		{
+			name:    "already exists",
+			status:  http.StatusOK,
+			body:    `{"status":"Error","error":"url already exists"}`,
+			err:     ErrAlreadyExists,
+			message: "url already exists",
+		},
+		{
+			name:    "validation",
+			status:  http.StatusOK,
+			body:    `{"status":"Error","error":"field URL is not a valid URL"}`,
+			err:     ErrInvalidRequest,
+			message: "field URL is not a valid URL",
+		},
+		{
+			name:   "not a member of the workspace",
+			status: http.StatusForbidden,
+			err:    ErrForbidden,
+		},
+		{
+			name:   "not an admin",
+			status: http.StatusUnauthorized,
+			err:    ErrUnauthorized,
+		},
+		{
+			name:   "server error",
+			status: http.StatusInternalServerError,
+			err:    ErrInternal,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			})
+
+			err := c.Delete(context.Background(), "test")
+			require.ErrorIs(t, err, tt.err)
+
+			var apiErr *Error
+			require.True(t, errors.As(err, &apiErr))
+			require.Equal(t, tt.status, apiErr.StatusCode)
+			require.Equal(t, tt.message, apiErr.Message)
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int64
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.Equal(t, "ads", r.URL.Query().Get("tag"))
+		_, _ = io.WriteString(w, `{"status":"OK","links":[{"alias":"test","url":"https://ya.ru"}]}`)
+	}, WithBearerToken("token"))
+
+	links, err := c.List(context.Background(), ListOptions{Tag: "ads"})
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	require.EqualValues(t, 3, calls.Load())
+
+	calls.Store(0)
+	_, err = c.Create(context.Background(), CreateRequest{URL: "https://ya.ru"})
+	require.ErrorIs(t, err, ErrInternal)
+	require.EqualValues(t, 1, calls.Load())
+
+	calls.Store(0)
+	down := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	_, err = down.List(context.Background(), ListOptions{})
+	require.ErrorIs(t, err, ErrInternal)
+	require.EqualValues(t, 3, calls.Load())
+}
+
+func TestRetriedDelete(t *testing.T) {
+	var calls atomic.Int64
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// The first delete goes through but its response is lost.
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		_, _ = io.WriteString(w, `{"status":"Error","error":"not found"}`)
+	})
+
+	require.NoError(t, c.Delete(context.Background(), "test"))
+	require.EqualValues(t, 2, calls.Load())
+
+	err := c.Delete(context.Background(), "test")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestUpdate(t *testing.T) {
+	var calls atomic.Int64
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		require.Equal(t, http.MethodPatch, r.Method)
+		require.Equal(t, "/url/test", r.URL.Path)
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"url":"https://go.dev","expires_at":"0001-01-01T00:00:00Z"}`, string(body))
+
+		if calls.Load() > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, `{"status":"OK","alias":"test","short_url":"https://sho.rt/test"}`)
+	})
+
+	destination, never := "https://go.dev", time.Time{}
+	updated, err := c.Update(context.Background(), "test", UpdateRequest{URL: &destination, ExpiresAt: &never})
+	require.NoError(t, err)
+	require.Equal(t, Created{Alias: "test", ShortURL: "https://sho.rt/test"}, updated)
+
+	_, err = c.Update(context.Background(), "test", UpdateRequest{URL: &destination, ExpiresAt: &never})
+	require.ErrorIs(t, err, ErrInternal)
+	require.EqualValues(t, 2, calls.Load())
+}
+
+func TestBatchCreate(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/url/import", r.URL.Path)
+		require.Equal(t, "jsonl", r.URL.Query().Get("format"))
+		require.Equal(t, OnConflictFail, r.URL.Query().Get("on_conflict"))
+		require.Equal(t, "key", r.Header.Get("X-API-Key"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, `{"alias":"new","url":"https://ya.ru"}`+"\n"+`{"alias":"taken","url":"https://go.dev"}`+"\n", string(body))
+
+		_, _ = io.WriteString(w, `{"status":"Error","error":"alias already exists in row 2","dry_run":false,"imported":1,"failed":1,`+
+			`"rows":[{"row":1,"alias":"new","result":"imported"},{"row":2,"alias":"taken","result":"failed","error":"alias already exists"}]}`)
+	}, WithAPIKey("key"))
+
+	res, err := c.BatchCreate(context.Background(), []BatchLink{
+		{Alias: "new", URL: "https://ya.ru"},
+		{Alias: "taken", URL: "https://go.dev"},
+	}, BatchOptions{OnConflict: OnConflictFail})
+	require.ErrorIs(t, err, ErrAlreadyExists)
+	require.Equal(t, 1, res.Imported)
+	require.Len(t, res.Rows, 2)
+}
+
+func TestGroupStats(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, "/url/stats", r.URL.Path)
+		require.Equal(t, "ads", r.URL.Query().Get("tag"))
+		require.False(t, r.URL.Query().Has("folder"))
+
+		_, _ = io.WriteString(w, `{"status":"OK","links":2,"variant_hits":5,"redeemed_clicks":1}`)
+	})
+
+	stats, err := c.GroupStats(context.Background(), GroupStatsOptions{Tag: "ads"})
+	require.NoError(t, err)
+	require.Equal(t, GroupStats{Links: 2, VariantHits: 5, RedeemedClicks: 1}, stats)
+}
+
+func TestResolve(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Empty(t, r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/eng/docs":
+			http.Redirect(w, r, "https://go.dev/doc", http.StatusFound)
+		case "/secret":
+			if r.Header.Get("X-Link-Password") == "password" {
+				http.Redirect(w, r, "https://ya.ru", http.StatusFound)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/once":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusGone)
+			_, _ = io.WriteString(w, `{"status":"Error","error":"gone"}`)
+		default:
+			_, _ = io.WriteString(w, `{"status":"Error","error":"not found"}`)
+		}
+	}, WithBasicAuth("admin@example.com", "secret"))
+
+	destination, err := c.Resolve(context.Background(), "eng/docs", "")
+	require.NoError(t, err)
+	require.Equal(t, "https://go.dev/doc", destination)
+
+	_, err = c.Resolve(context.Background(), "secret", "")
+	require.ErrorIs(t, err, ErrPasswordRequired)
+
+	destination, err = c.Resolve(context.Background(), "secret", "password")
+	require.NoError(t, err)
+	require.Equal(t, "https://ya.ru", destination)
+
+	_, err = c.Resolve(context.Background(), "once", "")
+	require.ErrorIs(t, err, ErrGone)
+
+	_, err = c.Resolve(context.Background(), "missing", "")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// A retry could use up another click.
+	var calls atomic.Int32
+	down := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	_, err = down.Resolve(context.Background(), "once", "")
+	require.ErrorIs(t, err, ErrInternal)
+	require.EqualValues(t, 1, calls.Load())
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const statusOK = "OK"
+
+// apiResponse is the status every JSON response of the API carries.
+type apiResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrGone            = errors.New("gone")
+	ErrInvalidPassword = errors.New("invalid password")
+	// ErrPasswordRequired is returned by Resolve for a protected link
+	// resolved without a password.
+	ErrPasswordRequired = errors.New("password required")
+	ErrTooManyAttempts  = errors.New("too many attempts")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrForbidden        = errors.New("forbidden")
+	ErrInvalidRequest   = errors.New("invalid request")
+	ErrInternal         = errors.New("internal error")
+)
+
+// messageErrors maps the errors of the responses to the errors of the
+// client. Every other message is a rejected request, e.g. a failed
+// validation.
+var messageErrors = map[string]error{
+	"not found":          ErrNotFound,
+	"url already exists": ErrAlreadyExists,
+	"gone":               ErrGone,
+	"invalid password":   ErrInvalidPassword,
+	"too many attempts":  ErrTooManyAttempts,
+	"internal error":     ErrInternal,
+	"failed to add url":  ErrInternal,
+}
+
+// Error is an error answered by the server. It wraps one of the Err
+// variables, to be checked with errors.Is.
+type Error struct {
+	StatusCode int
+	// Message is the error of the response, empty if it had no body.
+	Message string
+	err     error
+}
+
+func newError(statusCode int, message string) *Error {
+	e := &Error{StatusCode: statusCode, Message: message, err: ErrInvalidRequest}
+
+	if err, ok := messageErrors[message]; ok {
+		e.err = err
+		return e
+	}
+	// A batch fails on the first taken alias, naming its row.
+	if strings.HasPrefix(message, "alias already exists") {
+		e.err = ErrAlreadyExists
+		return e
+	}
+	if message != "" {
+		return e
+	}
+
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		e.err = ErrUnauthorized
+	case statusCode == http.StatusForbidden:
+		e.err = ErrForbidden
+	case statusCode == http.StatusNotFound:
+		e.err = ErrNotFound
+	case statusCode == http.StatusGone:
+		e.err = ErrGone
+	case statusCode == http.StatusTooManyRequests:
+		e.err = ErrTooManyAttempts
+	case statusCode >= http.StatusInternalServerError:
+		e.err = ErrInternal
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("client: %s (status %d)", e.err, e.StatusCode)
+	}
+	return fmt.Sprintf("client: %s (status %d)", e.Message, e.StatusCode)
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,440 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CreateRequest is a link to create. UTM and Query are merged into URL,
+// Destinations split the visitors between weighted urls.
+type CreateRequest struct {
+	URL          string            `json:"url"`
+	Alias        string            `json:"alias,omitempty"`
+	RedirectType int               `json:"redirect_type,omitempty"`
+	Passthrough  bool              `json:"passthrough,omitempty"`
+	UTM          *UTM              `json:"utm,omitempty"`
+	Query        map[string]string `json:"query,omitempty"`
+	Rules        []Rule            `json:"rules,omitempty"`
+	Destinations []Destination     `json:"destinations,omitempty"`
+	Password     string            `json:"password,omitempty"`
+	MaxClicks    int               `json:"max_clicks,omitempty"`
+	ActiveFrom   *time.Time        `json:"active_from,omitempty"`
+	Folder       string            `json:"folder,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+}
+
+// UpdateRequest changes the settings of a link, nil fields are kept. An
+// empty Folder takes the link out of its folder, a zero ExpiresAt makes it
+// never expire.
+type UpdateRequest struct {
+	URL          *string    `json:"url,omitempty"`
+	RedirectType *int       `json:"redirect_type,omitempty"`
+	Passthrough  *bool      `json:"passthrough,omitempty"`
+	Folder       *string    `json:"folder,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+type UTM struct {
+	Source   string `json:"source,omitempty"`
+	Medium   string `json:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty"`
+	Term     string `json:"term,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+// Rule redirects to URL when every non-empty condition matches the visitor.
+type Rule struct {
+	// Device is one of ios, android or desktop.
+	Device   string `json:"device,omitempty"`
+	Language string `json:"language,omitempty"`
+	Country  string `json:"country,omitempty"`
+	URL      string `json:"url"`
+}
+
+type Destination struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+type Created struct {
+	Alias    string `json:"alias"`
+	ShortURL string `json:"short_url"`
+}
+
+type Link struct {
+	Alias        string     `json:"alias"`
+	ShortURL     string     `json:"short_url"`
+	URL          string     `json:"url"`
+	RedirectType int        `json:"redirect_type,omitempty"`
+	Passthrough  bool       `json:"passthrough,omitempty"`
+	MaxClicks    int        `json:"max_clicks,omitempty"`
+	ActiveFrom   *time.Time `json:"active_from,omitempty"`
+	Folder       string     `json:"folder,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Owner        string     `json:"owner,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ListOptions narrows List, empty fields match anything. The server
+// returns 100 links if Limit is 0, 1000 at most.
+type ListOptions struct {
+	Tag    string
+	Folder string
+	Limit  int
+}
+
+// Stats are the counters of a link. Only links with MaxClicks and split
+// links count their visits.
+type Stats struct {
+	Alias           string             `json:"alias"`
+	Deleted         bool               `json:"deleted,omitempty"`
+	MaxClicks       int                `json:"max_clicks,omitempty"`
+	RemainingClicks int                `json:"remaining_clicks,omitempty"`
+	Destinations    []DestinationStats `json:"destinations,omitempty"`
+}
+
+// GroupStats sums the counters of the links of the workspace matching a
+// tag and a folder, deleted links are left out.
+type GroupStats struct {
+	Links          int64 `json:"links"`
+	VariantHits    int64 `json:"variant_hits"`
+	RedeemedClicks int64 `json:"redeemed_clicks"`
+}
+
+// GroupStatsOptions narrows GroupStats, empty fields match anything.
+type GroupStatsOptions struct {
+	Tag    string
+	Folder string
+}
+
+type DestinationStats struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+	Hits   int64  `json:"hits"`
+}
+
+const (
+	OnConflictSkip      = "skip"
+	OnConflictOverwrite = "overwrite"
+	OnConflictFail      = "fail"
+)
+
+// BatchLink is a link of BatchCreate, a random alias is picked if Alias is
+// empty.
+type BatchLink struct {
+	Alias  string   `json:"alias,omitempty"`
+	URL    string   `json:"url"`
+	Tags   []string `json:"tags,omitempty"`
+	Folder string   `json:"folder,omitempty"`
+	// ExpiresAt is when the link stops redirecting, nil for never.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type BatchOptions struct {
+	// OnConflict is what happens to a link whose alias is taken, one of the
+	// OnConflict constants. The server fails the whole batch by default.
+	OnConflict string
+	// DryRun checks the links without creating them.
+	DryRun bool
+}
+
+type BatchResult struct {
+	DryRun      bool       `json:"dry_run"`
+	Imported    int        `json:"imported"`
+	Overwritten int        `json:"overwritten"`
+	Skipped     int        `json:"skipped"`
+	Failed      int        `json:"failed"`
+	Rows        []BatchRow `json:"rows"`
+}
+
+// BatchRow is the result of a link of the batch, Row counts from 1.
+type BatchRow struct {
+	Row      int    `json:"row"`
+	Alias    string `json:"alias,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+	// Result is imported, overwritten, skipped or failed.
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Create creates a link, with a random alias if link.Alias is empty.
+func (c *Client) Create(ctx context.Context, link CreateRequest) (Created, error) {
+	const caller = "client.Create"
+
+	body, err := json.Marshal(link)
+	if err != nil {
+		return Created{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	var res struct {
+		apiResponse
+		Created
+	}
+	err = c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/url",
+		body:        body,
+		contentType: "application/json",
+		admin:       true,
+	}, &res)
+	if err != nil {
+		return Created{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return res.Created, nil
+}
+
+// BatchCreate creates links in a single transaction: either every valid
+// link is created or, if the batch fails, none. The result reports every
+// link, it is returned with the error of a failed batch too.
+func (c *Client) BatchCreate(ctx context.Context, links []BatchLink, opts BatchOptions) (BatchResult, error) {
+	const caller = "client.BatchCreate"
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, link := range links {
+		if err := enc.Encode(link); err != nil {
+			return BatchResult{}, fmt.Errorf("%s: %w", caller, err)
+		}
+	}
+
+	query := url.Values{"format": {"jsonl"}}
+	if opts.OnConflict != "" {
+		query.Set("on_conflict", opts.OnConflict)
+	}
+	if opts.DryRun {
+		query.Set("dry_run", "true")
+	}
+
+	var res struct {
+		apiResponse
+		BatchResult
+	}
+	err := c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/url/import",
+		query:       query,
+		body:        body.Bytes(),
+		contentType: "application/x-ndjson",
+		admin:       true,
+	}, &res)
+	if err != nil {
+		return res.BatchResult, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return res.BatchResult, nil
+}
+
+// Update changes the settings of a link and returns its short url.
+func (c *Client) Update(ctx context.Context, alias string, update UpdateRequest) (Created, error) {
+	const caller = "client.Update"
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return Created{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	var res struct {
+		apiResponse
+		Created
+	}
+	err = c.do(ctx, request{
+		method:      http.MethodPatch,
+		path:        "/url/" + url.PathEscape(alias),
+		body:        body,
+		contentType: "application/json",
+		admin:       true,
+	}, &res)
+	if err != nil {
+		return Created{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return res.Created, nil
+}
+
+// Delete deletes a link, it can be restored until the server purges it.
+func (c *Client) Delete(ctx context.Context, alias string) error {
+	const caller = "client.Delete"
+
+	err := c.do(ctx, request{method: http.MethodDelete, path: "/url/" + url.PathEscape(alias), admin: true}, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// Restore restores a deleted link.
+func (c *Client) Restore(ctx context.Context, alias string) error {
+	const caller = "client.Restore"
+
+	err := c.do(ctx, request{method: http.MethodPost, path: "/url/" + url.PathEscape(alias) + "/restore", admin: true}, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// AddTags adds tags to a link.
+func (c *Client) AddTags(ctx context.Context, alias string, tags ...string) error {
+	const caller = "client.AddTags"
+
+	body, err := json.Marshal(struct {
+		Tags []string `json:"tags"`
+	}{Tags: tags})
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	err = c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/url/" + url.PathEscape(alias) + "/tags",
+		body:        body,
+		contentType: "application/json",
+		admin:       true,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// RemoveTag removes a tag from a link, ErrNotFound if the link does not
+// have it.
+func (c *Client) RemoveTag(ctx context.Context, alias string, tag string) error {
+	const caller = "client.RemoveTag"
+
+	err := c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   "/url/" + url.PathEscape(alias) + "/tags",
+		query:  url.Values{"tag": {tag}},
+		admin:  true,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return nil
+}
+
+// List returns the links of the workspace, newest first.
+func (c *Client) List(ctx context.Context, opts ListOptions) ([]Link, error) {
+	const caller = "client.List"
+
+	query := url.Values{}
+	if opts.Tag != "" {
+		query.Set("tag", opts.Tag)
+	}
+	if opts.Folder != "" {
+		query.Set("folder", opts.Folder)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	var res struct {
+		apiResponse
+		Links []Link `json:"links"`
+	}
+	err := c.do(ctx, request{method: http.MethodGet, path: "/url", query: query, admin: true}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return res.Links, nil
+}
+
+// Stats returns the counters of a link, deleted links included.
+func (c *Client) Stats(ctx context.Context, alias string) (Stats, error) {
+	const caller = "client.Stats"
+
+	var res struct {
+		apiResponse
+		Stats
+	}
+	err := c.do(ctx, request{method: http.MethodGet, path: "/url/" + url.PathEscape(alias) + "/stats", admin: true}, &res)
+	if err != nil {
+		return Stats{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return res.Stats, nil
+}
+
+// GroupStats returns the counters of the links of the workspace, summed.
+func (c *Client) GroupStats(ctx context.Context, opts GroupStatsOptions) (GroupStats, error) {
+	const caller = "client.GroupStats"
+
+	query := url.Values{}
+	if opts.Tag != "" {
+		query.Set("tag", opts.Tag)
+	}
+	if opts.Folder != "" {
+		query.Set("folder", opts.Folder)
+	}
+
+	var res struct {
+		apiResponse
+		GroupStats
+	}
+	err := c.do(ctx, request{method: http.MethodGet, path: "/url/stats", query: query, admin: true}, &res)
+	if err != nil {
+		return GroupStats{}, fmt.Errorf("%s: %w", caller, err)
+	}
+
+	return res.GroupStats, nil
+}
+
+// Resolve returns the url alias redirects to, password is needed for
+// protected links only. The alias of a workspace with a prefix is given as
+// prefix/alias. Resolving is a visit: it uses up a click of a link with
+// max clicks and counts for a split link, so it is never retried.
+func (c *Client) Resolve(ctx context.Context, alias string, password string) (string, error) {
+	const caller = "client.Resolve"
+
+	header := http.Header{}
+	if password != "" {
+		header.Set(passwordHeader, password)
+	}
+
+	res, _, err := c.send(ctx, c.resolver, request{
+		method: http.MethodGet,
+		path:   "/" + strings.TrimPrefix(alias, "/"),
+		header: header,
+		once:   true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", caller, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices && res.StatusCode < http.StatusBadRequest {
+		location, err := res.Location()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", caller, err)
+		}
+		return location.String(), nil
+	}
+
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		// The password form of a protected link or the coming soon page of
+		// a scheduled one.
+		if res.StatusCode == http.StatusUnauthorized {
+			return "", fmt.Errorf("%s: %w", caller, &Error{StatusCode: res.StatusCode, err: ErrPasswordRequired})
+		}
+		return "", fmt.Errorf("%s: %w", caller, &Error{StatusCode: res.StatusCode, err: ErrNotFound})
+	}
+
+	if err = decode(res, nil); err == nil {
+		err = fmt.Errorf("unexpected response with status %d", res.StatusCode)
+	}
+	return "", fmt.Errorf("%s: %w", caller, err)
+}
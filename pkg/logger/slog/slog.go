@@ -1,6 +1,7 @@
 package slog
 
 import (
+	"io"
 	"log/slog"
 	"os"
 	"sync"
@@ -15,6 +16,27 @@ const (
 
 var log *slog.Logger
 var once sync.Once
+var output = &switchWriter{w: os.Stdout}
+
+// switchWriter lets the output be changed after the loggers of the packages
+// have been created.
+type switchWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// SetOutput redirects the logs, stdout by default.
+func SetOutput(w io.Writer) {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	output.w = w
+}
 
 func GetLogger() *slog.Logger {
 	once.Do(func() {
@@ -35,13 +57,13 @@ func setupLogger() *slog.Logger {
 	cfg := config.GetConfig()
 	switch cfg.Env {
 	case envLocal:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		log = slog.New(slog.NewTextHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	case envDev:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		log = slog.New(slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	case envProd:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		log = slog.New(slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	default:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		log = slog.New(slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	}
 	return log
 }